@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"splay/model"
+	"splay/pkg/stats"
+)
+
+// exitRegression 对比发现回退时的退出码，与其他失败（退出码 1）区分
+const exitRegression = 2
+
+// compareTolerances 判定回退的容差
+type compareTolerances struct {
+	QPSDropPercent          float64 // QPS 允许下降的比例（%）
+	LatencyIncreasePercent  float64 // 延迟允许上升的比例（%）
+	LatencyIncreaseMinMs    float64 // 延迟上升超过该绝对值（ms）才判定回退，避免低延迟时的抖动被误判
+	ErrorRateIncrease       float64 // 错误率允许上升的百分点
+	VerifyErrorRateIncrease float64 // 验证错误率允许上升的百分点
+}
+
+// comparison 一项指标的对比结果
+type comparison struct {
+	name      string
+	baseline  float64
+	candidate float64
+	regressed bool
+}
+
+// runCompare 执行 compare 子命令，返回进程退出码
+func runCompare(args []string) int {
+	var tol compareTolerances
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.Float64Var(&tol.QPSDropPercent, "max-qps-drop", 5, "平均发送和完成QPS允许下降的比例（%）")
+	fs.Float64Var(&tol.LatencyIncreasePercent, "max-latency-increase", 10, "平均延迟和百分位数允许上升的比例（%）")
+	fs.Float64Var(&tol.LatencyIncreaseMinMs, "min-latency-delta", 1, "延迟上升超过该值（ms）才判定回退")
+	fs.Float64Var(&tol.ErrorRateIncrease, "max-error-rate-increase", 0.5, "错误率允许上升的百分点")
+	fs.Float64Var(&tol.VerifyErrorRateIncrease, "max-verify-error-rate-increase", 0.5, "验证错误率允许上升的百分点")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s compare [选项] baseline.json candidate.json\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "对比两次压测保存的统计报告（见配置 report_file），发现回退时以退出码 %d 退出\n", exitRegression)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 1
+	}
+
+	baseline, err := loadReport(fs.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	candidate, err := loadReport(fs.Arg(1))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Printf("基线: %s\n候选: %s\n", fs.Arg(0), fs.Arg(1))
+	regressions := compareReports(baseline, candidate, tol)
+	if regressions > 0 {
+		fmt.Printf("\n发现 %d 项回退\n", regressions)
+		return exitRegression
+	}
+	fmt.Printf("\n未发现回退\n")
+	return 0
+}
+
+// loadReport 读取保存的统计报告
+func loadReport(path string) (*model.StatsReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取统计报告失败: %v", err)
+	}
+	var report model.StatsReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析统计报告 %s 失败: %v", path, err)
+	}
+	return &report, nil
+}
+
+// compareReports 打印两份报告的对比表格，返回回退的指标数
+func compareReports(baseline, candidate *model.StatsReport, tol compareTolerances) int {
+	var rows []comparison
+
+	// 吞吐量：下降超过容差判定回退
+	qpsRow := func(name string, base, cand float32) comparison {
+		return comparison{name, float64(base), float64(cand),
+			float64(cand) < float64(base)*(1-tol.QPSDropPercent/100)}
+	}
+	rows = append(rows,
+		qpsRow("平均发送QPS", baseline.PerformanceMetrics.AvgSentQPS, candidate.PerformanceMetrics.AvgSentQPS),
+		qpsRow("平均完成QPS", baseline.PerformanceMetrics.AvgCompletedQPS, candidate.PerformanceMetrics.AvgCompletedQPS))
+
+	// 错误率：上升超过容差（百分点）判定回退
+	baseErrorRate := float64(baseline.PerformanceMetrics.ErrorRate)
+	candErrorRate := float64(candidate.PerformanceMetrics.ErrorRate)
+	rows = append(rows, comparison{"错误率(%)", baseErrorRate, candErrorRate,
+		candErrorRate-baseErrorRate > tol.ErrorRateIncrease})
+	if baseline.TotalVerifyErrorRate != nil && candidate.TotalVerifyErrorRate != nil {
+		baseVerify := float64(*baseline.TotalVerifyErrorRate) * 100
+		candVerify := float64(*candidate.TotalVerifyErrorRate) * 100
+		rows = append(rows, comparison{"验证错误率(%)", baseVerify, candVerify,
+			candVerify-baseVerify > tol.VerifyErrorRateIncrease})
+	}
+
+	// 延迟：相对上升和绝对上升都超过容差才判定回退，只对比两次都有请求的操作
+	latencyRow := func(name string, base, cand float32) comparison {
+		delta := float64(cand - base)
+		return comparison{name, float64(base), float64(cand),
+			delta > tol.LatencyIncreaseMinMs && delta > float64(base)*tol.LatencyIncreasePercent/100}
+	}
+	operations := []struct {
+		name      string
+		baseline  model.LatencyDistribution
+		candidate model.LatencyDistribution
+	}{
+		{"上报", baseline.LatencyAnalysis.SensorData, candidate.LatencyAnalysis.SensorData},
+		{"读写", baseline.LatencyAnalysis.SensorRW, candidate.LatencyAnalysis.SensorRW},
+		{"批量", baseline.LatencyAnalysis.BatchRW, candidate.LatencyAnalysis.BatchRW},
+		{"查询", baseline.LatencyAnalysis.Query, candidate.LatencyAnalysis.Query},
+	}
+	for _, op := range operations {
+		if bucketTotal(op.baseline.Buckets) == 0 || bucketTotal(op.candidate.Buckets) == 0 {
+			continue
+		}
+		rows = append(rows, latencyRow(op.name+" 平均延迟(ms)", op.baseline.Avg, op.candidate.Avg))
+		if base, cand := op.baseline.Percentiles, op.candidate.Percentiles; base != nil && cand != nil {
+			rows = append(rows,
+				latencyRow(op.name+" P50(ms)", base.P50, cand.P50),
+				latencyRow(op.name+" P99(ms)", base.P99, cand.P99),
+				latencyRow(op.name+" P99.9(ms)", base.P999, cand.P999))
+		}
+	}
+
+	regressions := 0
+	fmt.Printf("\n=== 报告对比 ===\n")
+	fmt.Printf("%-24s %12s %12s %12s %9s\n", "指标", "基线", "候选", "变化", "变化率")
+	for _, row := range rows {
+		status := ""
+		if row.regressed {
+			status = "  回退"
+			regressions++
+		}
+		fmt.Printf("%-24s %12.2f %12.2f %+12.2f %9s%s\n",
+			row.name, row.baseline, row.candidate, row.candidate-row.baseline,
+			formatChange(row.baseline, row.candidate), status)
+	}
+
+	// 延迟分布：各桶占比的变化（百分点），只列出有请求的桶
+	fmt.Printf("\n=== 延迟分布对比（占比%%，基线 -> 候选）===\n")
+	for _, op := range operations {
+		baseTotal, candTotal := bucketTotal(op.baseline.Buckets), bucketTotal(op.candidate.Buckets)
+		if baseTotal == 0 || candTotal == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", op.name)
+		for i := range min(len(op.baseline.Buckets), len(op.candidate.Buckets)) {
+			base := float64(op.baseline.Buckets[i]) * 100 / float64(baseTotal)
+			cand := float64(op.candidate.Buckets[i]) * 100 / float64(candTotal)
+			if op.baseline.Buckets[i] == 0 && op.candidate.Buckets[i] == 0 {
+				continue
+			}
+			fmt.Printf("  %-9s %6.1f -> %6.1f (%+.1f)\n", stats.BucketLabel(i), base, cand, cand-base)
+		}
+	}
+
+	return regressions
+}
+
+// bucketTotal 计算延迟桶的总请求数
+func bucketTotal(buckets []int64) int64 {
+	var total int64
+	for _, count := range buckets {
+		total += count
+	}
+	return total
+}
+
+// formatChange 格式化相对变化率，基线为 0 时无法计算
+func formatChange(baseline, candidate float64) string {
+	if baseline == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (candidate-baseline)*100/baseline)
+}
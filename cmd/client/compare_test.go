@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"splay/model"
+)
+
+func TestCompareReports(t *testing.T) {
+	tol := compareTolerances{
+		QPSDropPercent:          5,
+		LatencyIncreasePercent:  10,
+		LatencyIncreaseMinMs:    1,
+		ErrorRateIncrease:       0.5,
+		VerifyErrorRateIncrease: 0.5,
+	}
+	report := func(completedQPS, errorRate, queryAvg float32) *model.StatsReport {
+		r := &model.StatsReport{}
+		r.PerformanceMetrics.AvgSentQPS = 1000
+		r.PerformanceMetrics.AvgCompletedQPS = completedQPS
+		r.PerformanceMetrics.ErrorRate = errorRate
+		r.LatencyAnalysis.Query = model.LatencyDistribution{Avg: queryAvg, Buckets: []int64{0, 10}}
+		return r
+	}
+	baseline := report(1000, 0.1, 20)
+
+	tests := []struct {
+		name      string
+		candidate *model.StatsReport
+		want      int
+	}{
+		{"完全相同", report(1000, 0.1, 20), 0},
+		{"QPS下降在容差内", report(960, 0.1, 20), 0},
+		{"QPS下降超过容差", report(940, 0.1, 20), 1},
+		{"错误率上升在容差内", report(1000, 0.5, 20), 0},
+		{"错误率上升超过容差", report(1000, 0.7, 20), 1},
+		{"延迟相对上升超过容差", report(1000, 0.1, 23), 1},
+		{"延迟相对上升未超过容差", report(1000, 0.1, 21.5), 0},
+		{"多项回退", report(900, 2, 40), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareReports(baseline, tt.candidate, tol); got != tt.want {
+				t.Errorf("compareReports = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// 低延迟的操作：相对上升超过容差但绝对上升不超过 min-latency-delta，不判定回退
+	lowBase, lowCand := report(1000, 0.1, 0.5), report(1000, 0.1, 1.2)
+	if got := compareReports(lowBase, lowCand, tol); got != 0 {
+		t.Errorf("低延迟抖动 compareReports = %d, want 0", got)
+	}
+
+	// 候选报告中没有请求的操作不参与延迟对比
+	empty := report(1000, 0.1, 100)
+	empty.LatencyAnalysis.Query.Buckets = []int64{0, 0}
+	if got := compareReports(baseline, empty, tol); got != 0 {
+		t.Errorf("没有请求的操作 compareReports = %d, want 0", got)
+	}
+}
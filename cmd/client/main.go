@@ -8,14 +8,26 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"splay/client"
 	"splay/pkg/config"
 	"splay/pkg/ratecontroller"
+	"splay/pkg/slo"
 	"splay/pkg/stats"
+	"splay/pkg/verifier"
+	"splay/pkg/worker"
 	"time"
 )
 
+// exitSLOViolation SLO 断言未通过时的退出码，与其他失败（退出码 1）区分
+const exitSLOViolation = 2
+
 func main() {
+	// compare 子命令：对比两次压测保存的统计报告
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(runCompare(os.Args[2:]))
+	}
+
 	var configFile string
 	var helpConfig bool
 	flag.StringVar(&configFile, "config", "config.json", "配置文件路径")
@@ -41,6 +53,9 @@ func main() {
 		log.Fatalf("配置验证失败: %v", err)
 	}
 
+	// 未指定随机种子时生成一个种子，并记录在配置输出和最终报告中
+	cfg.ResolveSeed()
+
 	// 打印配置信息
 	cfg.Print()
 
@@ -53,11 +68,36 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetDuration())
 	defer cancel()
 
-	// 3. 创建统计收集器
-	statsCollector := stats.NewCollector(ctx)
+	// 3. 创建统计收集器，压测结束后仍需接收持久化探测结果，生成报告前才停止
+	statsCtx, statsCancel := context.WithCancel(context.Background())
+	defer statsCancel()
+	statsCollector := stats.NewCollector(statsCtx)
 
-	// 4. 创建流量控制器
-	controller := ratecontroller.New(cfg, statsCollector, httpClient)
+	// 4. 创建工厂/设备拓扑模型和流量控制器
+	topology, err := worker.NewTopology(cfg.Topology, cfg.Seed)
+	if err != nil {
+		log.Fatalf("创建拓扑模型失败: %v", err)
+	}
+	if topology != nil {
+		fmt.Printf("拓扑模型: %s\n", topology.Summary())
+	}
+
+	// 5. 创建校验子系统（持久化探测、写入账本和业务规则校验），共用一个MySQL连接池
+	v, err := verifier.New(cfg, statsCollector)
+	if err != nil {
+		log.Fatalf("创建校验子系统失败: %v", err)
+	}
+	defer v.Close()
+
+	// 启动 Prometheus 指标接口，校验子系统的运行统计一并输出
+	if cfg.MetricsAddr != "" {
+		statsCollector.SetVerifierReport(v.Report)
+		if err := serveMetrics(cfg.MetricsAddr, statsCollector); err != nil {
+			log.Fatalf("启动指标接口失败: %v", err)
+		}
+	}
+
+	controller := ratecontroller.New(cfg, statsCollector, httpClient, topology, v)
 
 	// 6. 启动实时统计输出
 	go func() {
@@ -76,7 +116,13 @@ func main() {
 
 	fmt.Printf("开始压测，持续时间: %d 秒\n", cfg.Duration)
 	fmt.Printf("流量控制模式: %s\n", cfg.Mode)
-	if cfg.Mode == "qps" {
+	if len(cfg.Stages) > 0 {
+		fmt.Printf("负载阶段: %d 个\n", len(cfg.Stages))
+	} else if cfg.Mode == "search" {
+		fmt.Printf("容量搜索: 从 %d QPS 开始\n", cfg.Search.StartQPS)
+	} else if cfg.Mode == "adaptive" {
+		fmt.Printf("闭环速率控制: 从 %d QPS 开始 (%s)\n", cfg.Adaptive.StartQPS, cfg.Adaptive.Algorithm)
+	} else if cfg.Mode == "qps" {
 		fmt.Printf("目标QPS: %d (每个请求独立goroutine)\n", cfg.QPS)
 	} else {
 		fmt.Printf("并发数: %d (固定worker协程)\n", cfg.Concurrency)
@@ -85,22 +131,89 @@ func main() {
 	// 7. 启动流量控制器
 	controller.Start(ctx)
 
-	// 8. 等待测试完成或上下文取消
-	<-ctx.Done()
+	// 崩溃恢复测试：在指定时间触发服务端重启
+	if cfg.Restart != nil {
+		go scheduleRestart(ctx, cfg.Restart, statsCollector)
+	}
 
-	fmt.Println("\n测试时间到，正在停止...")
+	// 8. 等待测试完成、上下文取消或容量搜索完成
+	select {
+	case <-ctx.Done():
+		fmt.Println("\n测试时间到，正在停止...")
+	case <-controller.Done():
+		cancel()
+		fmt.Println("\n容量搜索完成，正在停止...")
+	}
 
-	// 10. 等待一段时间让剩余的goroutine完成
+	// 10. 等待一段时间让剩余的goroutine完成，再等待队列中的持久化探测结束
 	fmt.Println("等待剩余请求完成...")
 	time.Sleep(2 * time.Second)
+	fmt.Println("等待持久化探测完成...")
+	ledgerErr := v.Drain()
+	statsCancel()
 
 	// 11. 打印最终统计报告
 	fmt.Println("\n生成最终统计报告...")
 	statsCollector.PrintFinalReport()
+	v.PrintReport()
+	controller.PrintSearchResult()
+	controller.PrintAdaptiveResult()
+	controller.PrintArrivalStats()
+	controller.PrintSchedulerStats()
 
 	// 12. 生成并上报统计数据
 	fmt.Println("\n准备上报统计数据...")
 	statsReport := statsCollector.GetStatsReport()
+	statsReport.Seed = &cfg.Seed
+
+	statsReport.Verifier = v.Report()
+	statsReport.Search = controller.SearchResult()
+	statsReport.Adaptive = controller.AdaptiveResult()
+	statsReport.Arrival = controller.ArrivalStats()
+	statsReport.Scheduler = controller.SchedulerStats()
+
+	if ledgerErr != nil {
+		fmt.Printf("警告: %v\n", ledgerErr)
+	} else if cfg.LedgerFile != "" {
+		reconcileCtx, reconcileCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		result, err := v.Reconcile(reconcileCtx)
+		reconcileCancel()
+		if err != nil {
+			fmt.Printf("数据丢失对账失败: %v\n", err)
+		} else {
+			result.Print()
+			dataLossRate := float32(result.LossRate())
+			statsReport.TotalLostWrites = &result.Lost
+			statsReport.DataLossRate = &dataLossRate
+			if restart := statsReport.Restart; restart != nil {
+				lostBeforeRestart := result.LostBefore(restart.TriggeredAt)
+				restart.LostWritesBeforeRestart = &lostBeforeRestart
+				fmt.Printf("重启前已确认的丢失写入数: %d\n", lostBeforeRestart)
+			}
+		}
+	}
+
+	verifyCtx, verifyCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	result, err := v.VerifyBusinessRules(verifyCtx)
+	verifyCancel()
+	if err != nil {
+		fmt.Printf("业务规则校验失败: %v\n", err)
+	} else if result != nil {
+		result.Print()
+		// device_status 校验不可用时不给出失败总数，business_rule_errors 断言按无数据处理
+		if result.Available() {
+			totalBusinessRuleErrors := result.Total()
+			statsReport.TotalBusinessRuleErrors = &totalBusinessRuleErrors
+		}
+	}
+
+	// 13. 检查 SLO 断言，上报之后再以非零状态退出
+	sloPassed := true
+	if len(cfg.SLO) > 0 {
+		results := slo.Evaluate(statsReport, cfg.SLO)
+		slo.Print(results)
+		sloPassed = slo.AllPassed(results)
+	}
 
 	s, err := json.Marshal(statsReport)
 	if err != nil {
@@ -110,10 +223,37 @@ func main() {
 	fmt.Println("==========上报数据==========\n", string(s))
 	fmt.Println("==========上报数据==========")
 
+	if cfg.ReportFile != "" {
+		if err := os.WriteFile(cfg.ReportFile, s, 0644); err != nil {
+			fmt.Printf("保存统计报告失败: %v\n", err)
+		} else {
+			fmt.Printf("统计报告已保存到 %s\n", cfg.ReportFile)
+		}
+	}
+
+	// SLO 退出码不受上报结果影响，上报失败只在断言全部通过时以退出码 1 退出
+	uploadErr := uploadStats(cfg, s)
+	if uploadErr != nil {
+		fmt.Printf("上报统计数据失败: %v\n", uploadErr)
+	} else {
+		fmt.Println("上报统计数据成功")
+	}
+
+	if !sloPassed {
+		fmt.Println("SLO 断言未通过")
+		os.Exit(exitSLOViolation)
+	}
+	if uploadErr != nil {
+		os.Exit(1)
+	}
+}
+
+// uploadStats 将统计报告上报到 report_url
+func uploadStats(cfg *config.Config, s []byte) error {
 	// 创建请求
 	req, err := http.NewRequest("POST", cfg.ReportURL, bytes.NewBuffer(s))
 	if err != nil {
-		log.Fatalf("Failed to create request: %v", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// 设置 Content-Type
@@ -125,17 +265,15 @@ func main() {
 	// 发送请求
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Fatalf("Failed to report stats: %v", err)
+		return fmt.Errorf("failed to report stats: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		log.Fatalf("Server returned error status: %d", resp.StatusCode)
+		return fmt.Errorf("server returned error status: %d", resp.StatusCode)
 	}
-
-	fmt.Println("上报统计数据成功")
-
+	return nil
 }
 
 // printConfigHelp 显示配置结构说明
@@ -149,9 +287,23 @@ func printConfigHelp() {
 	fmt.Println("  duration_seconds    int      测试持续时间（秒）(默认: 60)")
 	fmt.Println()
 	fmt.Println("流量控制配置：")
-	fmt.Println("  mode                string   流量控制模式: \"qps\" 或 \"concurrency\" (默认: qps)")
+	fmt.Println("  mode                string   流量控制模式: \"qps\"、\"concurrency\"、\"search\" 或 \"adaptive\" (默认: qps)")
 	fmt.Println("  qps                 int      目标QPS（mode=qps时使用）(默认: 100)")
 	fmt.Println("  concurrency         int      并发数（mode=concurrency时使用）(默认: 10)")
+	fmt.Println("  arrival             object   请求到达过程（qps/search/adaptive模式），长期平均速率等于目标QPS (默认: {\"type\": \"constant\"})")
+	fmt.Println("                               type: \"constant\" 均匀; \"poisson\" 泊松; \"onoff\" 突发: on_seconds(1) 发送、off_seconds(1) 停止;")
+	fmt.Println("                               \"diurnal\" 正弦: period_seconds(60) 周期、amplitude(0.5) 相对幅度")
+	fmt.Println("  stages              array    负载阶段 [{duration_seconds, qps, concurrency, ramp}]，按阶段调整目标QPS或并发数 (默认: [])")
+	fmt.Println("                               ramp: \"step\"(默认) 阶段开始即切换; \"linear\"/\"exponential\" 从上一阶段目标值逐渐变化")
+	fmt.Println("                               配置后 qps、concurrency 和 duration_seconds 不再生效，各阶段统计分别报告")
+	fmt.Println("  search              object   容量搜索（mode=search时使用），duration_seconds 为最长搜索时间 (默认: {\"start_qps\": 100,")
+	fmt.Println("                               \"max_qps\": 100000, \"step_seconds\": 10, \"drain_seconds\": 5, \"growth_factor\": 2,")
+	fmt.Println("                               \"precision\": 0.05, \"max_p99_ms\": 500, \"max_error_rate\": 1, \"max_pending_ratio\": 0.05})")
+	fmt.Println("                               每次探测后最多等待 drain_seconds 秒排空在途请求，duration_seconds 至少容纳 6 次探测")
+	fmt.Println("  adaptive            object   闭环速率控制（mode=adaptive时使用），按 target(\"p99\"|\"inflight\") 的目标值")
+	fmt.Println("                               target_p99_ms(500)/target_inflight(100) 用 algorithm(\"aimd\"|\"pid\") 每 interval_ms(1000) 调整QPS")
+	fmt.Println("                               start_qps(100) min_qps(1) max_qps(100000); aimd: increase_qps(50) decrease_factor(0.7);")
+	fmt.Println("                               pid: kp(0.5) ki(0.1) kd(0.05)，误差按目标值归一化，输出为QPS的相对调整量")
 	fmt.Println()
 	fmt.Println("操作比例配置（总和应≤1.0）：")
 	fmt.Println("  sensor_data_ratio   float64  传感器数据上报比例 (默认: 0.4)")
@@ -160,15 +312,52 @@ func printConfigHelp() {
 	fmt.Println("  query_ratio         float64  查询操作比例 (默认: 0.1)")
 	fmt.Println()
 	fmt.Println("数据配置：")
+	fmt.Println("  seed                int      数据生成的随机种子，相同种子和配置生成相同的数据序列，0 表示随机 (默认: 0)")
+	fmt.Println("  run_index           int      运行序号，与种子一起决定写入指纹，对同一数据库重复相同种子的压测时递增，配置写入账本时数据库中已有相同运行ID的标记行会拒绝启动 (默认: 0)")
 	fmt.Println("  key_range           int      设备ID范围 (默认: 1000)")
 	fmt.Println("  report_interval     int      实时报告间隔（秒）(默认: 5)")
+	fmt.Println("  topology            object   工厂/设备拓扑模型，不配置时设备ID均匀随机")
+	fmt.Println("                               factories: [{id,devices,metrics,weight}] 显式指定工厂列表，或使用生成参数:")
+	fmt.Println("                               factory_count(3000) devices_min/max(20/50) metrics_min/max(2/8)")
+	fmt.Println("                               skew(\"zipf\"|\"pareto\"|\"uniform\") skew_param(1.0) seed(0 表示使用全局种子)")
+	fmt.Println("  sampling_clock      object   传感器采样时钟 (默认: {\"enabled\": false, \"tick_ms\": 1000, \"duplicate_ratio\": 0.6})")
+	fmt.Println("                               启用后时间戳按采样周期对齐重复，QPS模式下对应比例的请求在周期边界突发发送")
+	fmt.Println("  payload_size        object   data 字段负载大小分布（字节），需要写入指纹时不能小于 28 (默认: {\"type\": \"fixed\", \"value\": 64})")
+	fmt.Println("                               type=lognormal: median/sigma，按 min/max 截断(max 必填); type=histogram: buckets[{min,max,weight}]")
+	fmt.Println("  batch_size          object   批量操作每批条数分布 (默认: {\"type\": \"fixed\", \"value\": 10})")
+	fmt.Println("                               type=fixed: value; type=uniform: min/max; type=weighted: values/weights")
+	fmt.Println("  query_limit         int      查询操作每页记录数 (默认: 100)")
+	fmt.Println("  query_max_pages     int      查询操作最多翻页数 (默认: 3)")
 	fmt.Println()
 	fmt.Println("MySQL配置：")
 	fmt.Println("  mysql_dsn           string   MySQL数据源名称 (默认: \"\")")
+	fmt.Println("  verify_business_rules bool   压测结束后校验告警优先级和 device_status (默认: false)")
+	fmt.Println("  device_status       object   device_status 表名和列名，列名为空时跳过对应校验 (默认: {\"table\": \"device_status\",")
+	fmt.Println("                               \"device_id_column\": \"device_id\", \"last_value_column\": \"last_value\",")
+	fmt.Println("                               \"alert_count_column\": \"alert_count\", \"updated_at_column\": \"updated_at\"})")
+	fmt.Println("  persistence_sla_ms  int      写入确认到数据在MySQL中可见的时限，超过计为落盘超时 (默认: 1000)")
+	fmt.Println("  persistence_timeout_ms int   持久化探测最长等待时间，超过仍不可见视为未落盘 (默认: 10000)")
+	fmt.Println("  verifier            object   校验子系统 (默认: {\"sample_rate\": 0.01, \"queue_size\": 1000, \"workers\": 8,")
+	fmt.Println("                               \"max_open_conns\": 8, \"max_idle_conns\": 8, \"conn_max_lifetime_sec\": 300})")
+	fmt.Println("                               sample_rate: 确认的写入中做持久化探测的比例，队列满时丢弃探测")
+	fmt.Println("  restart             object   崩溃恢复测试，不配置时不重启服务端")
+	fmt.Println("                               offset_seconds: 压测开始后触发重启的时间; command: 重启命令(sh -c 执行);")
+	fmt.Println("                               或 pid + signal(\"TERM\"|\"KILL\"|\"INT\"|\"HUP\"，默认 TERM) 向服务端进程发送信号")
+	fmt.Println("  ledger_file         string   写入账本文件，记录确认写入的指纹，压测结束后对账统计数据丢失，为空时不对账 (默认: \"\")")
 	fmt.Println()
 	fmt.Println("上报配置：")
 	fmt.Println("  report_url          string   统计数据上报URL (默认: \"\")")
 	fmt.Println("  report_key          string   上报认证密钥，用于设置 X-Team-ID 和 X-Team-Name header (默认: \"\")")
+	fmt.Println("  report_file         string   最终报告的保存路径，用于 compare 子命令对比两次压测，为空时不保存 (默认: \"\")")
+	fmt.Println()
+	fmt.Println("SLO 断言：")
+	fmt.Println("  slo                 array    [{metric, min, max}] 压测结束后逐项检查最终报告，任一项不满足时以退出码 2 退出 (默认: [])")
+	fmt.Println("                               metric: avg_sent_qps, avg_completed_qps, error_rate, latency_p50_ms, latency_p99_ms,")
+	fmt.Println("                               latency_p999_ms, response_time_p99_ms, persistence_p99_ms, save_delay_errors,")
+	fmt.Println("                               data_loss_rate, lost_writes, business_rule_errors")
+	fmt.Println()
+	fmt.Println("监控配置：")
+	fmt.Println("  metrics_addr        string   Prometheus 指标监听地址，如 \":9100\"，在 /metrics 输出实时统计，为空时不启动 (默认: \"\")")
 	fmt.Println()
 	fmt.Println("示例配置文件 (config.json)：")
 	fmt.Println(`{
@@ -182,6 +371,8 @@ func printConfigHelp() {
   "query_ratio": 0.1,
   "key_range": 1000,
   "report_interval": 5,
+  "payload_size": {"type": "lognormal", "median": 2048, "sigma": 0.8, "min": 512, "max": 20480},
+  "batch_size": {"type": "weighted", "values": [10, 100, 500], "weights": [0.6, 0.3, 0.1]},
   "mysql_dsn": "",
   "report_url": "http://monitoring-server/api/stats",
   "report_key": "your-team-key"  // 将同时设置 X-Team-ID 和 X-Team-Name header
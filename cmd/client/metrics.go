@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"splay/pkg/stats"
+)
+
+// serveMetrics 在 addr 上提供 /metrics 接口，以 Prometheus 文本格式输出客户端实时统计
+// 监听失败时返回错误，监听成功后在后台提供服务直到进程退出
+func serveMetrics(addr string, statsCollector *stats.Collector) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听指标地址 %s 失败: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", statsCollector.MetricsHandler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			fmt.Printf("指标服务已停止: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("指标接口: http://%s/metrics\n", listener.Addr())
+	return nil
+}
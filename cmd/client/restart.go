@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"splay/pkg/config"
+	"splay/pkg/stats"
+)
+
+// restartSignals 崩溃恢复测试支持的信号
+var restartSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+}
+
+// scheduleRestart 在压测开始 offset_seconds 秒后触发服务端重启，压测提前结束时不触发
+func scheduleRestart(ctx context.Context, cfg *config.RestartConfig, statsCollector *stats.Collector) {
+	timer := time.NewTimer(cfg.GetOffset())
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	statsCollector.MarkRestart(time.Now())
+	if err := triggerRestart(ctx, cfg); err != nil {
+		fmt.Printf("触发服务端重启失败: %v\n", err)
+	}
+}
+
+// triggerRestart 执行重启命令或向服务端进程发送信号
+func triggerRestart(ctx context.Context, cfg *config.RestartConfig) error {
+	if cfg.Command != "" {
+		fmt.Printf("\n触发服务端重启: %s\n", cfg.Command)
+		// 重启命令通常在后台启动新的服务端进程，它会继承命令的输出；
+		// 直接使用客户端的标准输出而不经过管道，避免等待命令结束时被长期运行的子进程阻塞
+		cmd := exec.CommandContext(ctx, "sh", "-c", cfg.Command)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.WaitDelay = time.Second
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("执行重启命令失败: %v", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n触发服务端重启: 向进程 %d 发送 SIG%s\n", cfg.PID, cfg.Signal)
+	process, err := os.FindProcess(cfg.PID)
+	if err != nil {
+		return fmt.Errorf("查找进程 %d 失败: %v", cfg.PID, err)
+	}
+	if err := process.Signal(restartSignals[cfg.Signal]); err != nil {
+		return fmt.Errorf("向进程 %d 发送信号失败: %v", cfg.PID, err)
+	}
+	return nil
+}
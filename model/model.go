@@ -3,6 +3,103 @@
 // Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
 package model
 
+import (
+	"time"
+)
+
+// AdaptivePoint 一个控制周期的调整结果
+type AdaptivePoint struct {
+	// CompletedQPS 周期内的完成QPS
+	CompletedQPS float32 `json:"completedQPS"`
+
+	// Elapsed 周期结束时距压测开始的时间（秒）
+	Elapsed float32 `json:"elapsed"`
+
+	// Measured 周期内的被控量，周期内没有完成的请求时不返回
+	Measured *float32 `json:"measured,omitempty"`
+
+	// Qps 调整后的目标QPS
+	Qps int64 `json:"qps"`
+}
+
+// AdaptiveResult 闭环速率控制结果，仅 adaptive 模式返回
+type AdaptiveResult struct {
+	// Algorithm 控制算法，aimd 或 pid
+	Algorithm string `json:"algorithm"`
+
+	// SteadyCompletedQPS 稳态完成QPS，即服务端在目标值下提供的吞吐量
+	SteadyCompletedQPS float32 `json:"steadyCompletedQPS"`
+
+	// SteadyMeasured 稳态被控量的平均值
+	SteadyMeasured float32 `json:"steadyMeasured"`
+
+	// SteadyQPS 稳态发送QPS，后一半控制周期的平均值
+	SteadyQPS float32 `json:"steadyQPS"`
+
+	// Target 控制目标，p99（响应时间P99）或 inflight（在途请求数）
+	Target string `json:"target"`
+
+	// TargetValue 目标值（P99 为 ms）
+	TargetValue float32 `json:"targetValue"`
+
+	// Trajectory 每个控制周期的调整结果
+	Trajectory []AdaptivePoint `json:"trajectory"`
+}
+
+// ArrivalStats QPS模式下实际产生的请求到达间隔分布（含采样时钟的突发请求），并发模式不返回
+type ArrivalStats struct {
+	// ActualQPS 由平均到达间隔计算的实际发送速率
+	ActualQPS float32 `json:"actualQPS"`
+
+	// Cv 到达间隔的变异系数（标准差/平均值），均匀到达接近0，泊松到达接近1，突发到达大于1
+	Cv float32 `json:"cv"`
+
+	// Intervals 统计的到达间隔数
+	Intervals int64 `json:"intervals"`
+
+	// MeanIntervalMs 平均到达间隔（ms）
+	MeanIntervalMs float32 `json:"meanIntervalMs"`
+
+	// Percentiles 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	Percentiles LatencyPercentiles `json:"percentiles"`
+
+	// Type 到达过程，constant、poisson、onoff 或 diurnal
+	Type string `json:"type"`
+}
+
+// ErrorBreakdown 业务操作失败请求的错误分类，用于区分服务端过载和客户端连接耗尽
+type ErrorBreakdown struct {
+	// ConnectionRefused 连接被拒绝，服务端未监听或已崩溃
+	ConnectionRefused int64 `json:"connectionRefused"`
+
+	// ConnectionReset 连接被重置或提前关闭
+	ConnectionReset int64 `json:"connectionReset"`
+
+	// Decode 响应解析失败
+	Decode int64 `json:"decode"`
+
+	// Http4xx 服务端返回 4xx
+	Http4xx int64 `json:"http4xx"`
+
+	// Http503 服务端返回 503，通常表示过载
+	Http503 int64 `json:"http503"`
+
+	// Http5xx 服务端返回 503 以外的 5xx
+	Http5xx int64 `json:"http5xx"`
+
+	// Other 无法归类的错误
+	Other int64 `json:"other"`
+
+	// SocketExhausted 客户端本地端口或文件描述符耗尽
+	SocketExhausted int64 `json:"socketExhausted"`
+
+	// StatusCodes 按HTTP状态码统计的失败请求数
+	StatusCodes *map[string]int64 `json:"statusCodes,omitempty"`
+
+	// Timeout 请求超时
+	Timeout int64 `json:"timeout"`
+}
+
 // HighPriorityStats 高优先级请求统计（Priority≥3）
 type HighPriorityStats struct {
 	// Percentage 高优先级请求占比（%）
@@ -11,14 +108,32 @@ type HighPriorityStats struct {
 	// SensorDataCount 传感器数据上报高优先级请求数
 	SensorDataCount int64 `json:"sensorDataCount"`
 
+	// SensorRWCount 传感器读写操作高优先级请求数
+	SensorRWCount int64 `json:"sensorRWCount"`
+
 	// TotalCount 高优先级请求总数
 	TotalCount int64 `json:"totalCount"`
 }
 
 // LatencyAnalysis 延迟分析
 type LatencyAnalysis struct {
+	// BatchRW 延迟分布统计
+	BatchRW LatencyDistribution `json:"batchRW"`
+
+	// Persistence 延迟分布统计
+	Persistence *LatencyDistribution `json:"persistence,omitempty"`
+
+	// Query 延迟分布统计
+	Query LatencyDistribution `json:"query"`
+
+	// ResponseTime 响应时间分析，从计划发送时刻到请求完成的时间，QPS模式下包含客户端排队延迟（修正协调遗漏），并发模式下等于服务时间
+	ResponseTime *ResponseTimeAnalysis `json:"responseTime,omitempty"`
+
 	// SensorData 延迟分布统计
 	SensorData LatencyDistribution `json:"sensorData"`
+
+	// SensorRW 延迟分布统计
+	SensorRW LatencyDistribution `json:"sensorRW"`
 }
 
 // LatencyDistribution 延迟分布统计
@@ -44,26 +159,64 @@ type LatencyDistribution struct {
 	// HighPriorityMin 高优先级最小延迟（ms）
 	HighPriorityMin *float32 `json:"highPriorityMin,omitempty"`
 
+	// HighPriorityPercentiles 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	HighPriorityPercentiles *LatencyPercentiles `json:"highPriorityPercentiles,omitempty"`
+
 	// Max 最大延迟（ms）
 	Max float32 `json:"max"`
 
 	// Min 最小延迟（ms）
 	Min float32 `json:"min"`
+
+	// Percentiles 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	Percentiles *LatencyPercentiles `json:"percentiles,omitempty"`
+}
+
+// LatencyPercentiles 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+type LatencyPercentiles struct {
+	P50 float32 `json:"p50"`
+	P90 float32 `json:"p90"`
+	P95 float32 `json:"p95"`
+	P99 float32 `json:"p99"`
+
+	// P999 P99.9
+	P999 float32 `json:"p999"`
 }
 
 // OperationStat 单个操作类型的统计
 type OperationStat struct {
+	// BytesSent 已发送的负载数据（data 字段）字节数
+	BytesSent *int64 `json:"bytesSent,omitempty"`
+
 	// Errors 错误数量
 	Errors int64 `json:"errors"`
 
 	// Operations 操作数量
 	Operations int64 `json:"operations"`
+
+	// PageOverlaps 翻页查询时与之前的页重复的记录数（仅查询操作），查询期间的新写入会使分页错位，不计为校验失败
+	PageOverlaps *int64 `json:"pageOverlaps,omitempty"`
+
+	// Rows 成功写入的数据条数（仅批量操作）
+	Rows *int64 `json:"rows,omitempty"`
+
+	// ValidationErrors 响应校验失败数量（请求成功但返回内容不符合预期）
+	ValidationErrors *int64 `json:"validationErrors,omitempty"`
 }
 
 // OperationsStats 各类操作统计
 type OperationsStats struct {
+	// BatchRW 单个操作类型的统计
+	BatchRW OperationStat `json:"batchRW"`
+
+	// Query 单个操作类型的统计
+	Query OperationStat `json:"query"`
+
 	// SensorData 单个操作类型的统计
 	SensorData OperationStat `json:"sensorData"`
+
+	// SensorRW 单个操作类型的统计
+	SensorRW OperationStat `json:"sensorRW"`
 }
 
 // PerformanceMetrics 性能指标
@@ -71,15 +224,165 @@ type PerformanceMetrics struct {
 	// AvgCompletedQPS 平均完成 QPS
 	AvgCompletedQPS float32 `json:"avgCompletedQPS"`
 
+	// AvgSentBytesPerSecond 平均每秒发送的负载数据字节数
+	AvgSentBytesPerSecond *float32 `json:"avgSentBytesPerSecond,omitempty"`
+
 	// AvgSentQPS 平均发送 QPS
 	AvgSentQPS float32 `json:"avgSentQPS"`
 
+	// BatchRowsPerSecond 批量操作平均每秒写入行数
+	BatchRowsPerSecond *float32 `json:"batchRowsPerSecond,omitempty"`
+
 	// ErrorRate 错误率（%）
 	ErrorRate float32 `json:"errorRate"`
 }
 
+// ResponseTimeAnalysis 响应时间分析，从计划发送时刻到请求完成的时间，QPS模式下包含客户端排队延迟（修正协调遗漏），并发模式下等于服务时间
+type ResponseTimeAnalysis struct {
+	// BatchRW 延迟分布统计
+	BatchRW LatencyDistribution `json:"batchRW"`
+
+	// Query 延迟分布统计
+	Query LatencyDistribution `json:"query"`
+
+	// SensorData 延迟分布统计
+	SensorData LatencyDistribution `json:"sensorData"`
+
+	// SensorRW 延迟分布统计
+	SensorRW LatencyDistribution `json:"sensorRW"`
+}
+
+// RestartStats 崩溃恢复测试统计，客户端视角的服务端重启中断窗口，未配置重启时不返回
+type RestartStats struct {
+	// ErrorsDuringOutage 中断窗口内完成的失败请求数
+	ErrorsDuringOutage int64 `json:"errorsDuringOutage"`
+
+	// FirstErrorMs 触发后第一个失败请求的完成时间（ms，相对触发时刻）
+	FirstErrorMs *float32 `json:"firstErrorMs,omitempty"`
+
+	// LostWritesBeforeRestart 触发重启前已确认、对账时 time_series_data 中不存在的写入数，未配置写入账本时不返回
+	LostWritesBeforeRestart *int64 `json:"lostWritesBeforeRestart,omitempty"`
+
+	// OutageWindowMs 中断窗口，第一个失败请求到恢复后第一个成功请求之间的时间（ms）
+	OutageWindowMs *float32 `json:"outageWindowMs,omitempty"`
+
+	// Recovered 压测结束前服务是否恢复
+	Recovered bool `json:"recovered"`
+
+	// RecoveryTimeMs 恢复时间，触发后第一个失败之后第一个成功请求的完成时间（ms，相对触发时刻）
+	RecoveryTimeMs *float32 `json:"recoveryTimeMs,omitempty"`
+
+	// TriggeredAt 触发重启的时刻
+	TriggeredAt time.Time `json:"triggeredAt"`
+}
+
+// SchedulerStats QPS模式下调度器的调度延迟和实际发送速率，并发模式不返回
+type SchedulerStats struct {
+	// AchievedQPS 实际发送速率
+	AchievedQPS float32 `json:"achievedQPS"`
+
+	// Dispatched 调度器发送的请求数（含采样时钟的突发请求）
+	Dispatched int64 `json:"dispatched"`
+
+	// Lag 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	Lag LatencyPercentiles `json:"lag"`
+
+	// MaxLagMs 最大调度延迟（ms）
+	MaxLagMs float32 `json:"maxLagMs"`
+
+	// MeanLagMs 平均调度延迟（实际发送时刻晚于计划发送时刻的时间，ms）
+	MeanLagMs float32 `json:"meanLagMs"`
+
+	// TargetQPS 按时间平均的目标QPS
+	TargetQPS float32 `json:"targetQPS"`
+}
+
+// SearchPoint 一次容量探测的测量结果
+type SearchPoint struct {
+	// CompletedQPS 探测期间的完成QPS：探测及排空期间完成的请求数除以探测时间
+	CompletedQPS float32 `json:"completedQPS"`
+
+	// ErrorRate 探测期间的错误率（%）
+	ErrorRate float32 `json:"errorRate"`
+
+	// Limit 未通过时超过的阈值
+	Limit *string `json:"limit,omitempty"`
+
+	// P99 探测期间的响应时间P99（ms）
+	P99 float32 `json:"p99"`
+
+	// Passed 是否满足所有阈值
+	Passed bool `json:"passed"`
+
+	// PendingRatio 探测结束时仍未完成的请求占探测期间发送请求的比例（0-1）
+	PendingRatio float32 `json:"pendingRatio"`
+
+	// TargetQPS 探测的目标QPS
+	TargetQPS int64 `json:"targetQPS"`
+}
+
+// SearchResult 容量搜索结果，仅 search 模式返回
+type SearchResult struct {
+	// Converged 搜索是否在压测时间内完成
+	Converged bool `json:"converged"`
+
+	// Curve 按探测顺序排列的测量结果
+	Curve []SearchPoint `json:"curve"`
+
+	// Limit 首先触发的限制，p99（响应时间P99）、error_rate（错误率）、pending（未完成请求比例）或 max_qps（达到配置的最大QPS）
+	Limit *string `json:"limit,omitempty"`
+
+	// MaxSustainableQPS 满足所有阈值的最高QPS
+	MaxSustainableQPS int64 `json:"maxSustainableQPS"`
+}
+
+// StageStats 一个负载阶段的统计，包含阶段时间窗口内完成的业务请求
+type StageStats struct {
+	AvgCompletedQPS float32 `json:"avgCompletedQPS"`
+	AvgSentQPS      float32 `json:"avgSentQPS"`
+
+	// Elapsed 阶段持续时间（秒）
+	Elapsed float32 `json:"elapsed"`
+
+	// ErrorRate 错误率（%）
+	ErrorRate float32 `json:"errorRate"`
+
+	// Index 阶段序号，从0开始
+	Index int `json:"index"`
+
+	// Latency 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	Latency LatencyPercentiles `json:"latency"`
+
+	// Ramp 阶段的变化方式（step、linear 或 exponential）
+	Ramp string `json:"ramp"`
+
+	// ResponseTime 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	ResponseTime LatencyPercentiles `json:"responseTime"`
+
+	// StartElapsed 阶段开始时距压测开始的时间（秒）
+	StartElapsed float32 `json:"startElapsed"`
+
+	// Target 阶段的目标QPS（qps模式）或并发数（concurrency模式）
+	Target      int64 `json:"target"`
+	TotalErrors int64 `json:"totalErrors"`
+	TotalOps    int64 `json:"totalOps"`
+	TotalSent   int64 `json:"totalSent"`
+}
+
 // StatsReport 最终统计报告
 type StatsReport struct {
+	// Adaptive 闭环速率控制结果，仅 adaptive 模式返回
+	Adaptive *AdaptiveResult `json:"adaptive,omitempty"`
+
+	// Arrival QPS模式下实际产生的请求到达间隔分布（含采样时钟的突发请求），并发模式不返回
+	Arrival *ArrivalStats `json:"arrival,omitempty"`
+
+	// DataLossRate 数据丢失率（%），丢失写入数占确认写入数的比例，未配置写入账本时不返回
+	DataLossRate *float32 `json:"dataLossRate,omitempty"`
+
+	// ErrorBreakdown 业务操作失败请求的错误分类，用于区分服务端过载和客户端连接耗尽
+	ErrorBreakdown ErrorBreakdown `json:"errorBreakdown"`
+
 	// HighPriorityAvgDelayLatency 高优先级平均延迟（ms）
 	HighPriorityAvgDelayLatency *float32 `json:"highPriorityAvgDelayLatency,omitempty"`
 
@@ -98,24 +401,105 @@ type StatsReport struct {
 	// PerformanceMetrics 性能指标
 	PerformanceMetrics PerformanceMetrics `json:"performanceMetrics"`
 
+	// Restart 崩溃恢复测试统计，客户端视角的服务端重启中断窗口，未配置重启时不返回
+	Restart *RestartStats `json:"restart,omitempty"`
+
+	// Scheduler QPS模式下调度器的调度延迟和实际发送速率，并发模式不返回
+	Scheduler *SchedulerStats `json:"scheduler,omitempty"`
+
+	// Search 容量搜索结果，仅 search 模式返回
+	Search *SearchResult `json:"search,omitempty"`
+
+	// Seed 数据生成使用的随机种子，相同种子和配置可重现相同的请求序列
+	Seed *int64 `json:"seed,omitempty"`
+
+	// Stages 各负载阶段的统计，按阶段顺序排列，未配置负载阶段时不返回
+	Stages *[]StageStats `json:"stages,omitempty"`
+
+	// Timeline 按统计周期（report_interval）记录的快照，按时间顺序排列，最多保留最近 3600 个周期
+	Timeline *[]TimelinePoint `json:"timeline,omitempty"`
+
 	// TotalAvgLatency 总平均延迟（ms）
 	TotalAvgLatency *float32 `json:"totalAvgLatency,omitempty"`
 
+	// TotalBusinessRuleErrors 业务规则校验失败总数（device_status 最新值/告警计数不一致、告警数据优先级错误），未启用校验时不返回
+	TotalBusinessRuleErrors *int64 `json:"totalBusinessRuleErrors,omitempty"`
+
+	// TotalBytesSent 已发送的负载数据（data 字段）总字节数
+	TotalBytesSent *int64 `json:"totalBytesSent,omitempty"`
+
 	// TotalElapsed 总运行时间（秒）
 	TotalElapsed float32 `json:"totalElapsed"`
 
 	// TotalErrors 总错误数
 	TotalErrors int64 `json:"totalErrors"`
 
+	// TotalLostWrites 数据丢失数，服务端确认但对账时 time_series_data 中不存在的写入数，未配置写入账本时不返回
+	TotalLostWrites *int64 `json:"totalLostWrites,omitempty"`
+
 	// TotalOps 完成请求数
 	TotalOps int64 `json:"totalOps"`
 
-	// TotalSaveDelayErrors 总因为发现落盘时间超时而产生的错误数
+	// TotalSaveDelayErrors 总因为发现落盘时间超时而产生的错误数（超过持久化时限才可见或探测超时仍不可见的写入数）
 	TotalSaveDelayErrors int64 `json:"totalSaveDelayErrors"`
 
 	// TotalSent 发送请求数
 	TotalSent int64 `json:"totalSent"`
 
-	// TotalVerifyErrorRate 总验证错误率（%）, 验证完成请求 3s 后数据是否落盘。
+	// TotalVerifyErrorRate 总验证错误率（%）, 持久化探测在最长探测时间内未发现数据的比例。
 	TotalVerifyErrorRate *float32 `json:"totalVerifyErrorRate,omitempty"`
+
+	// Verifier 校验子系统运行统计，用于判断持久化探测是否成为瓶颈
+	Verifier *VerifierStats `json:"verifier,omitempty"`
+}
+
+// TimelinePoint 一个统计周期的快照
+type TimelinePoint struct {
+	// CompletedQPS 周期内的完成速率（含验证请求）
+	CompletedQPS float32 `json:"completedQPS"`
+
+	// Elapsed 周期结束时距压测开始的时间（秒）
+	Elapsed float32 `json:"elapsed"`
+
+	// Errors 周期内失败的业务请求数
+	Errors int64 `json:"errors"`
+
+	// Pending 周期结束时的待处理请求数
+	Pending int64 `json:"pending"`
+
+	// Percentiles 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	Percentiles LatencyPercentiles `json:"percentiles"`
+
+	// SentQPS 周期内的发送速率（含验证请求）
+	SentQPS float32 `json:"sentQPS"`
+
+	// VerifyErrors 周期内失败的验证请求数
+	VerifyErrors int64 `json:"verifyErrors"`
+}
+
+// VerifierStats 校验子系统运行统计，用于判断持久化探测是否成为瓶颈
+type VerifierStats struct {
+	// Dropped 被丢弃的探测数，包括队列已满和在队列中等待超过最长探测时间的探测
+	Dropped int64 `json:"dropped"`
+
+	// Expired 其中在队列中等待超过最长探测时间而丢弃的探测数
+	Expired int64 `json:"expired"`
+
+	// MaxOpenConnections MySQL连接池最大连接数
+	MaxOpenConnections int64 `json:"maxOpenConnections"`
+
+	// PoolWaitCount 等待MySQL连接池空闲连接的次数
+	PoolWaitCount int64 `json:"poolWaitCount"`
+
+	// PoolWaitDurationMs 等待MySQL连接池空闲连接的累计时间（ms）
+	PoolWaitDurationMs float32 `json:"poolWaitDurationMs"`
+
+	// QueueDepth 排队等待执行的持久化探测数
+	QueueDepth int64 `json:"queueDepth"`
+
+	// QueueWait 延迟百分位数（ms），由对数线性直方图计算，相对误差不超过 0.8%
+	QueueWait LatencyPercentiles `json:"queueWait"`
+
+	// Sampled 按采样率抽中进行持久化探测的写入数
+	Sampled int64 `json:"sampled"`
 }
@@ -20,44 +20,683 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
+// maxPayloadSize data 字段负载的最大字节数
+const maxPayloadSize = 1 << 20
+
+// fingerprintLen 嵌入在 data 负载末尾的写入指纹长度，与 verifier 包一致
+const fingerprintLen = 28
+
+// maxFingerprintWorkers 写入指纹可区分的Worker数，与 verifier 包一致
+const maxFingerprintWorkers = 1 << 20
+
 type Config struct {
 	// 服务器配置
 	ServerURL string `json:"server_url"`
 	Duration  int    `json:"duration_seconds"` // 使用秒数，方便配置文件
 
 	// 流量控制配置
-	Mode        string `json:"mode"` // "qps" 或 "concurrency"
+	Mode        string `json:"mode"` // "qps"、"concurrency"、"search" 或 "adaptive"
 	QPS         int    `json:"qps"`
 	Concurrency int    `json:"concurrency"`
 
+	// QPS模式下请求的到达过程，search 和 adaptive 模式同样适用
+	Arrival ArrivalConfig `json:"arrival"`
+
+	// 分阶段负载配置，配置后按阶段依次调整目标QPS（qps模式）或并发数（concurrency模式），
+	// 压测持续时间为各阶段持续时间之和，qps、concurrency 和 duration_seconds 不再生效
+	Stages []StageConfig `json:"stages,omitempty"`
+
+	// 容量搜索配置（search模式），duration_seconds 为搜索的最长时间
+	Search SearchConfig `json:"search"`
+
+	// 闭环速率控制配置（adaptive模式）
+	Adaptive AdaptiveConfig `json:"adaptive"`
+
+	// 操作比例配置（总和应≤1.0）
+	SensorDataRatio float64 `json:"sensor_data_ratio"` // 传感器数据上报比例
+	SensorRWRatio   float64 `json:"sensor_rw_ratio"`   // 传感器读写操作比例
+	BatchRWRatio    float64 `json:"batch_rw_ratio"`    // 批量操作比例
+	QueryRatio      float64 `json:"query_ratio"`       // 查询操作比例
+
 	// 数据配置
-	KeyRange       int `json:"key_range"`       // 设备ID范围
-	ReportInterval int `json:"report_interval"` // 报告间隔（秒）
+	Seed           int64            `json:"seed"`            // 数据生成的随机种子，0 表示随机生成一个种子
+	RunIndex       int              `json:"run_index"`       // 运行序号，与种子一起决定写入指纹的运行ID，对同一数据库重复相同种子的压测时递增
+	KeyRange       int              `json:"key_range"`       // 设备ID范围
+	ReportInterval int              `json:"report_interval"` // 报告间隔（秒）
+	PayloadSize    SizeDistribution `json:"payload_size"`    // data 字段负载大小分布（字节）
+	BatchSize      SizeDistribution `json:"batch_size"`      // 批量操作每批数据条数分布
+	QueryLimit     int              `json:"query_limit"`     // 查询操作每页记录数
+	QueryMaxPages  int              `json:"query_max_pages"` // 查询操作最多翻页数
+
+	// 工厂/设备拓扑配置，不配置时设备ID在工厂和设备范围内均匀随机
+	Topology *TopologyConfig `json:"topology,omitempty"`
+
+	// 传感器采样时钟配置，未启用时使用请求发送时刻作为时间戳
+	SamplingClock SamplingClockConfig `json:"sampling_clock"`
 
 	// MySQL配置
-	MySQLDSN string `json:"mysql_dsn"` // MySQL数据源名称
+	MySQLDSN             string             `json:"mysql_dsn"`              // MySQL数据源名称
+	VerifyBusinessRules  bool               `json:"verify_business_rules"`  // 压测结束后校验 device_status 和告警优先级等业务规则
+	DeviceStatus         DeviceStatusConfig `json:"device_status"`          // 服务端 device_status 表的表名和列名
+	PersistenceSLAMs     int                `json:"persistence_sla_ms"`     // 写入确认后数据在MySQL中可见的时限（毫秒），超过计为落盘超时
+	PersistenceTimeoutMs int                `json:"persistence_timeout_ms"` // 持久化探测的最长等待时间（毫秒），超过仍不可见视为未落盘
+	LedgerFile           string             `json:"ledger_file"`            // 写入账本文件路径，记录确认的写入用于压测结束后对账，为空时不对账
+
+	// 校验子系统配置（MySQL连接池、探测队列和采样率）
+	Verifier VerifierConfig `json:"verifier"`
+
+	// 崩溃恢复测试配置，不配置时不重启服务端
+	Restart *RestartConfig `json:"restart,omitempty"`
 
 	// 上报配置
-	ReportURL string `json:"report_url"` // 上报URL
-	ReportKey string `json:"report_key"` // 上报密钥
+	ReportURL  string `json:"report_url"`  // 上报URL
+	ReportKey  string `json:"report_key"`  // 上报密钥
+	ReportFile string `json:"report_file"` // 最终报告的保存路径，用于 compare 子命令对比两次压测，为空时不保存
+
+	// SLO 断言，压测结束后对最终报告逐项检查，任一项不满足时进程以非零状态退出
+	SLO []SLOConfig `json:"slo,omitempty"`
+
+	// 监控配置
+	MetricsAddr string `json:"metrics_addr"` // Prometheus 指标监听地址（如 ":9100"），为空时不启动
 
 	durationTime       time.Duration `json:"-"`
 	reportIntervalTime time.Duration `json:"-"`
 }
 
+// SizeDistribution 数量分布配置
+type SizeDistribution struct {
+	Type    string       `json:"type"`              // 分布类型: "fixed"、"uniform"、"weighted"、"lognormal" 或 "histogram"
+	Value   int          `json:"value,omitempty"`   // fixed: 固定值
+	Min     int          `json:"min,omitempty"`     // uniform: 最小值（含）; lognormal: 截断下限
+	Max     int          `json:"max,omitempty"`     // uniform: 最大值（含）; lognormal: 截断上限
+	Values  []int        `json:"values,omitempty"`  // weighted: 候选值
+	Weights []float64    `json:"weights,omitempty"` // weighted: 各候选值的权重
+	Median  float64      `json:"median,omitempty"`  // lognormal: 中位数
+	Sigma   float64      `json:"sigma,omitempty"`   // lognormal: 对数标准差
+	Buckets []SizeBucket `json:"buckets,omitempty"` // histogram: 直方图区间
+}
+
+// SizeBucket 直方图分布的一个区间，区间内均匀取值
+type SizeBucket struct {
+	Min    int     `json:"min"`    // 区间最小值（含）
+	Max    int     `json:"max"`    // 区间最大值（含）
+	Weight float64 `json:"weight"` // 区间权重
+}
+
+// Validate 验证分布配置，所有可能取值必须在 [lower, upper] 范围内
+func (d *SizeDistribution) Validate(name string, lower, upper int) error {
+	inRange := func(v int) bool { return v >= lower && v <= upper }
+
+	switch d.Type {
+	case "fixed":
+		if !inRange(d.Value) {
+			return fmt.Errorf("%s.value 必须在 %d-%d 之间", name, lower, upper)
+		}
+	case "uniform", "lognormal":
+		if !inRange(d.Min) || !inRange(d.Max) || d.Min > d.Max {
+			return fmt.Errorf("%s 的 min/max 必须在 %d-%d 之间且 min≤max", name, lower, upper)
+		}
+		if d.Type == "lognormal" && (d.Median <= 0 || d.Sigma < 0) {
+			return fmt.Errorf("%s.median 必须大于0且 sigma 不能为负数", name)
+		}
+		// 省略 min/max 时截断区间为 [0, 0]，所有取值都会被截断为0
+		if d.Type == "lognormal" && (d.Max <= 0 || float64(d.Max) < d.Median) {
+			return fmt.Errorf("%s.max 必须大于0且不小于 median", name)
+		}
+	case "weighted":
+		if len(d.Values) == 0 || len(d.Values) != len(d.Weights) {
+			return fmt.Errorf("%s.values 不能为空且必须与 weights 数量一致", name)
+		}
+		totalWeight := 0.0
+		for i, v := range d.Values {
+			if !inRange(v) {
+				return fmt.Errorf("%s.values 必须在 %d-%d 之间", name, lower, upper)
+			}
+			if d.Weights[i] < 0 {
+				return fmt.Errorf("%s.weights 不能为负数", name)
+			}
+			totalWeight += d.Weights[i]
+		}
+		if totalWeight <= 0 {
+			return fmt.Errorf("%s.weights 总和必须大于0", name)
+		}
+	case "histogram":
+		if len(d.Buckets) == 0 {
+			return fmt.Errorf("%s.buckets 不能为空", name)
+		}
+		totalWeight := 0.0
+		for _, bucket := range d.Buckets {
+			if !inRange(bucket.Min) || !inRange(bucket.Max) || bucket.Min > bucket.Max {
+				return fmt.Errorf("%s.buckets 的 min/max 必须在 %d-%d 之间且 min≤max", name, lower, upper)
+			}
+			if bucket.Weight < 0 {
+				return fmt.Errorf("%s.buckets 的 weight 不能为负数", name)
+			}
+			totalWeight += bucket.Weight
+		}
+		if totalWeight <= 0 {
+			return fmt.Errorf("%s.buckets 的 weight 总和必须大于0", name)
+		}
+	default:
+		return fmt.Errorf("无效的 %s.type: %s, 必须是 'fixed'、'uniform'、'weighted'、'lognormal' 或 'histogram'", name, d.Type)
+	}
+	return nil
+}
+
+// String 返回分布的简要描述
+func (d *SizeDistribution) String() string {
+	switch d.Type {
+	case "fixed":
+		return fmt.Sprintf("固定 %d", d.Value)
+	case "uniform":
+		return fmt.Sprintf("均匀分布 %d-%d", d.Min, d.Max)
+	case "weighted":
+		return fmt.Sprintf("加权 %v (权重 %v)", d.Values, d.Weights)
+	case "lognormal":
+		return fmt.Sprintf("对数正态分布 中位数=%.0f sigma=%.2f (截断 %d-%d)", d.Median, d.Sigma, d.Min, d.Max)
+	case "histogram":
+		parts := make([]string, 0, len(d.Buckets))
+		for _, bucket := range d.Buckets {
+			parts = append(parts, fmt.Sprintf("%d-%d:%.2f", bucket.Min, bucket.Max, bucket.Weight))
+		}
+		return fmt.Sprintf("直方图 [%s]", strings.Join(parts, ", "))
+	}
+	return d.Type
+}
+
+// TopologyConfig 工厂/设备拓扑配置
+// 指定 factories 时直接使用给定的工厂列表，否则按生成参数和种子生成
+type TopologyConfig struct {
+	Factories []FactoryConfig `json:"factories,omitempty"` // 显式指定的工厂列表
+
+	FactoryCount int     `json:"factory_count"` // 生成的工厂数量
+	DevicesMin   int     `json:"devices_min"`   // 每个工厂的最少设备数
+	DevicesMax   int     `json:"devices_max"`   // 每个工厂的最多设备数
+	MetricsMin   int     `json:"metrics_min"`   // 每个工厂的最少指标种类数
+	MetricsMax   int     `json:"metrics_max"`   // 每个工厂的最多指标种类数
+	Skew         string  `json:"skew"`          // 工厂负载分布: "zipf"、"pareto" 或 "uniform"
+	SkewParam    float64 `json:"skew_param"`    // zipf 的指数 s 或 pareto 的形状参数 alpha
+	Seed         int64   `json:"seed"`          // 生成拓扑的随机种子，0 表示使用全局种子
+}
+
+// FactoryConfig 单个工厂的拓扑配置
+type FactoryConfig struct {
+	ID      int      `json:"id"`      // 工厂ID
+	Devices int      `json:"devices"` // 设备数量
+	Metrics []string `json:"metrics"` // 该工厂使用的指标名称
+	Weight  float64  `json:"weight"`  // 负载权重
+}
+
+// Validate 验证拓扑配置
+func (t *TopologyConfig) Validate() error {
+	if len(t.Factories) > 0 {
+		totalWeight := 0.0
+		for _, f := range t.Factories {
+			if f.Devices <= 0 || len(f.Metrics) == 0 || f.Weight < 0 {
+				return fmt.Errorf("topology.factories 中工厂 %d 的设备数和指标列表不能为空，权重不能为负数", f.ID)
+			}
+			totalWeight += f.Weight
+		}
+		if totalWeight <= 0 {
+			return fmt.Errorf("topology.factories 的权重总和必须大于0")
+		}
+		return nil
+	}
+
+	if t.FactoryCount <= 0 {
+		return fmt.Errorf("topology.factory_count 必须大于0")
+	}
+	if t.DevicesMin <= 0 || t.DevicesMin > t.DevicesMax {
+		return fmt.Errorf("topology.devices_min/devices_max 必须大于0且 min≤max")
+	}
+	if t.MetricsMin <= 0 || t.MetricsMin > t.MetricsMax {
+		return fmt.Errorf("topology.metrics_min/metrics_max 必须大于0且 min≤max")
+	}
+	switch t.Skew {
+	case "uniform":
+	case "zipf", "pareto":
+		if t.SkewParam <= 0 {
+			return fmt.Errorf("topology.skew_param 必须大于0")
+		}
+	default:
+		return fmt.Errorf("无效的 topology.skew: %s, 必须是 'zipf'、'pareto' 或 'uniform'", t.Skew)
+	}
+	return nil
+}
+
+// VerifierConfig 校验子系统配置
+// 持久化探测在固定数量的校验协程中执行，共用一个有上限的MySQL连接池，
+// 队列满时丢弃探测任务而不是阻塞压测Worker，保证校验不会成为瓶颈
+type VerifierConfig struct {
+	SampleRate         float64 `json:"sample_rate"`           // 确认的写入中进行持久化探测的比例
+	QueueSize          int     `json:"queue_size"`            // 探测任务队列长度，队列满时丢弃
+	Workers            int     `json:"workers"`               // 执行探测的校验协程数
+	MaxOpenConns       int     `json:"max_open_conns"`        // MySQL连接池最大连接数
+	MaxIdleConns       int     `json:"max_idle_conns"`        // MySQL连接池最大空闲连接数
+	ConnMaxLifetimeSec int     `json:"conn_max_lifetime_sec"` // MySQL连接最长存活时间（秒），0 表示不限制
+}
+
+// Validate 验证校验子系统配置
+func (v *VerifierConfig) Validate() error {
+	if v.SampleRate < 0 || v.SampleRate > 1 {
+		return fmt.Errorf("verifier.sample_rate 必须在 0-1 之间")
+	}
+	if v.QueueSize <= 0 {
+		return fmt.Errorf("verifier.queue_size 必须大于0")
+	}
+	if v.Workers <= 0 {
+		return fmt.Errorf("verifier.workers 必须大于0")
+	}
+	if v.MaxOpenConns <= 0 {
+		return fmt.Errorf("verifier.max_open_conns 必须大于0")
+	}
+	if v.MaxIdleConns < 0 || v.MaxIdleConns > v.MaxOpenConns {
+		return fmt.Errorf("verifier.max_idle_conns 必须在 0 到 max_open_conns 之间")
+	}
+	if v.ConnMaxLifetimeSec < 0 {
+		return fmt.Errorf("verifier.conn_max_lifetime_sec 不能为负数")
+	}
+	return nil
+}
+
+// GetConnMaxLifetime 获取MySQL连接最长存活时间
+func (v *VerifierConfig) GetConnMaxLifetime() time.Duration {
+	return time.Duration(v.ConnMaxLifetimeSec) * time.Second
+}
+
+// DeviceStatusConfig 服务端 device_status 表的表名和列名
+// 业务规则校验按配置读取，不猜测服务端的表结构；列名为空表示明确跳过对应的校验，
+// 配置的表或列在数据库中不存在时 device_status 校验不可用，报告中不给出业务规则校验失败数
+type DeviceStatusConfig struct {
+	Table            string `json:"table"`              // 表名
+	DeviceIDColumn   string `json:"device_id_column"`   // 设备ID列
+	LastValueColumn  string `json:"last_value_column"`  // 最新值列
+	AlertCountColumn string `json:"alert_count_column"` // 告警计数列
+	UpdatedAtColumn  string `json:"updated_at_column"`  // 更新时间列
+}
+
+// Validate 验证 device_status 表配置，表名和列名直接拼接在查询中，只允许字母、数字和下划线
+func (d *DeviceStatusConfig) Validate() error {
+	if d.Table == "" || d.DeviceIDColumn == "" {
+		return fmt.Errorf("device_status.table 和 device_status.device_id_column 不能为空")
+	}
+	for _, name := range []string{d.Table, d.DeviceIDColumn, d.LastValueColumn, d.AlertCountColumn, d.UpdatedAtColumn} {
+		for _, r := range name {
+			if !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return fmt.Errorf("device_status 中的表名和列名只能包含字母、数字和下划线: %q", name)
+			}
+		}
+	}
+	return nil
+}
+
+// RestartConfig 崩溃恢复测试配置
+// 在压测开始 offset_seconds 秒后执行重启命令，或向服务端进程发送信号，统计客户端视角的中断窗口和恢复时间
+type RestartConfig struct {
+	OffsetSeconds int    `json:"offset_seconds"` // 压测开始后触发重启的时间（秒）
+	Command       string `json:"command"`        // 重启命令，通过 sh -c 执行
+	PID           int    `json:"pid"`            // 接收信号的服务端进程ID，与 command 二选一
+	Signal        string `json:"signal"`         // 发送的信号: "TERM"、"KILL"、"INT" 或 "HUP"，默认 "TERM"
+}
+
+// Validate 验证崩溃恢复测试配置，duration 为压测持续时间（秒）
+func (r *RestartConfig) Validate(duration int) error {
+	if r.OffsetSeconds <= 0 || r.OffsetSeconds >= duration {
+		return fmt.Errorf("restart.offset_seconds 必须在 0 到 duration_seconds 之间")
+	}
+	if (r.Command == "") == (r.PID == 0) {
+		return fmt.Errorf("restart.command 和 restart.pid 必须且只能配置一个")
+	}
+	if r.PID < 0 {
+		return fmt.Errorf("restart.pid 不能为负数")
+	}
+	switch r.Signal {
+	case "", "TERM", "KILL", "INT", "HUP":
+	default:
+		return fmt.Errorf("无效的 restart.signal: %s, 必须是 'TERM'、'KILL'、'INT' 或 'HUP'", r.Signal)
+	}
+	return nil
+}
+
+// GetOffset 获取触发重启的时间
+func (r *RestartConfig) GetOffset() time.Duration {
+	return time.Duration(r.OffsetSeconds) * time.Second
+}
+
+// ArrivalConfig 请求到达过程配置，各种到达过程的长期平均速率都等于目标QPS
+// constant: 均匀间隔; poisson: 指数分布的随机间隔;
+// onoff: 每个周期内 on_seconds 秒以 (on+off)/on 倍的速率发送，其后 off_seconds 秒不发送;
+// diurnal: 速率按 1+amplitude*sin(2πt/period_seconds) 正弦变化，模拟昼夜负载
+type ArrivalConfig struct {
+	Type          string  `json:"type"`           // 到达过程: "constant"、"poisson"、"onoff" 或 "diurnal"
+	OnSeconds     float64 `json:"on_seconds"`     // onoff: 每个周期内发送的时间（秒）
+	OffSeconds    float64 `json:"off_seconds"`    // onoff: 每个周期内不发送的时间（秒）
+	PeriodSeconds float64 `json:"period_seconds"` // diurnal: 正弦变化的周期（秒）
+	Amplitude     float64 `json:"amplitude"`      // diurnal: 速率变化的幅度，相对于目标QPS，0-1之间
+}
+
+// Validate 验证到达过程配置
+func (a *ArrivalConfig) Validate() error {
+	switch a.Type {
+	case "constant", "poisson":
+	case "onoff":
+		if a.OnSeconds <= 0 || a.OffSeconds < 0 {
+			return fmt.Errorf("arrival.on_seconds 必须大于0，arrival.off_seconds 不能为负数")
+		}
+	case "diurnal":
+		if a.PeriodSeconds <= 0 {
+			return fmt.Errorf("arrival.period_seconds 必须大于0")
+		}
+		if a.Amplitude < 0 || a.Amplitude >= 1 {
+			return fmt.Errorf("arrival.amplitude 必须在 0-1 之间（不含1）")
+		}
+	default:
+		return fmt.Errorf("无效的 arrival.type: %s, 必须是 'constant'、'poisson'、'onoff' 或 'diurnal'", a.Type)
+	}
+	return nil
+}
+
+// String 返回到达过程的文本描述
+func (a *ArrivalConfig) String() string {
+	switch a.Type {
+	case "onoff":
+		return fmt.Sprintf("突发 (发送 %.1fs / 停止 %.1fs)", a.OnSeconds, a.OffSeconds)
+	case "diurnal":
+		return fmt.Sprintf("正弦 (周期 %.0fs, 幅度 %.0f%%)", a.PeriodSeconds, a.Amplitude*100)
+	case "poisson":
+		return "泊松"
+	}
+	return "均匀"
+}
+
+// StageConfig 负载阶段配置
+// ramp 为 step 时阶段开始即切换到目标值；linear 和 exponential 从上一阶段的目标值（第一个阶段从0）
+// 线性或指数变化，在阶段结束时达到目标值
+type StageConfig struct {
+	DurationSeconds int    `json:"duration_seconds"` // 阶段持续时间（秒）
+	QPS             int    `json:"qps"`              // 阶段目标QPS（qps模式）
+	Concurrency     int    `json:"concurrency"`      // 阶段目标并发数（concurrency模式）
+	Ramp            string `json:"ramp"`             // 变化方式: "step"、"linear" 或 "exponential"，默认 "step"
+}
+
+// Validate 验证负载阶段配置，mode 为流量控制模式
+func (s *StageConfig) Validate(mode string) error {
+	if s.DurationSeconds <= 0 {
+		return fmt.Errorf("stages.duration_seconds 必须大于0")
+	}
+	if mode == "qps" && s.QPS <= 0 {
+		return fmt.Errorf("stages.qps 必须大于0")
+	}
+	if mode == "concurrency" && s.Concurrency <= 0 {
+		return fmt.Errorf("stages.concurrency 必须大于0")
+	}
+	switch s.Ramp {
+	case "step", "linear", "exponential":
+	default:
+		return fmt.Errorf("无效的 stages.ramp: %s, 必须是 'step'、'linear' 或 'exponential'", s.Ramp)
+	}
+	return nil
+}
+
+// Target 获取阶段在指定模式下的目标值
+func (s *StageConfig) Target(mode string) int {
+	if mode == "concurrency" {
+		return s.Concurrency
+	}
+	return s.QPS
+}
+
+// GetDuration 获取阶段持续时间
+func (s *StageConfig) GetDuration() time.Duration {
+	return time.Duration(s.DurationSeconds) * time.Second
+}
+
+// SearchConfig 容量搜索配置
+// 从 start_qps 开始按 growth_factor 倍数提高QPS，直到响应时间P99、错误率或未完成请求比例超过阈值，
+// 再在最后通过和首次失败的QPS之间二分查找，区间小于 precision 时结束；
+// 每次探测后暂停发送，最多等待 drain_seconds 秒让未完成的请求结束，避免积压计入下一次探测
+type SearchConfig struct {
+	StartQPS        int     `json:"start_qps"`         // 初始QPS
+	MaxQPS          int     `json:"max_qps"`           // 最大QPS，达到后不再提高
+	StepSeconds     int     `json:"step_seconds"`      // 每个QPS的探测时间（秒）
+	DrainSeconds    int     `json:"drain_seconds"`     // 探测结束后等待未完成请求结束的最长时间（秒）
+	GrowthFactor    float64 `json:"growth_factor"`     // 探测失败前每次提高QPS的倍数
+	Precision       float64 `json:"precision"`         // 二分查找的结束条件：区间宽度与已通过QPS的比例
+	MaxP99Ms        float64 `json:"max_p99_ms"`        // 响应时间P99阈值（毫秒），从计划发送时刻计算
+	MaxErrorRate    float64 `json:"max_error_rate"`    // 错误率阈值（%）
+	MaxPendingRatio float64 `json:"max_pending_ratio"` // 探测期间发送但未完成的请求比例阈值，超过说明服务端处理速度跟不上
+}
+
+// Validate 验证容量搜索配置
+func (s *SearchConfig) Validate() error {
+	if s.StartQPS <= 0 {
+		return fmt.Errorf("search.start_qps 必须大于0")
+	}
+	if s.MaxQPS < s.StartQPS {
+		return fmt.Errorf("search.max_qps 不能小于 search.start_qps")
+	}
+	if s.StepSeconds <= 0 {
+		return fmt.Errorf("search.step_seconds 必须大于0")
+	}
+	if s.DrainSeconds < 0 {
+		return fmt.Errorf("search.drain_seconds 不能为负数")
+	}
+	if s.GrowthFactor <= 1 {
+		return fmt.Errorf("search.growth_factor 必须大于1")
+	}
+	if s.Precision <= 0 || s.Precision >= 1 {
+		return fmt.Errorf("search.precision 必须在 0-1 之间")
+	}
+	if s.MaxP99Ms <= 0 || s.MaxErrorRate < 0 || s.MaxPendingRatio < 0 {
+		return fmt.Errorf("search 的 max_p99_ms 必须大于0，max_error_rate 和 max_pending_ratio 不能为负数")
+	}
+	return nil
+}
+
+// GetStep 获取每个QPS的探测时间
+func (s *SearchConfig) GetStep() time.Duration {
+	return time.Duration(s.StepSeconds) * time.Second
+}
+
+// GetDrain 获取探测结束后等待未完成请求结束的最长时间
+func (s *SearchConfig) GetDrain() time.Duration {
+	return time.Duration(s.DrainSeconds) * time.Second
+}
+
+// minSearchProbes 容量搜索至少需要的探测次数，少于它时倍增和二分查找都无法得到有意义的结果
+const minSearchProbes = 6
+
+// AdaptiveConfig 闭环速率控制配置
+// 每个控制周期读取最近一个周期的响应时间P99或在途请求数，用 AIMD 或 PID 调整发送速率，使其稳定在目标值
+type AdaptiveConfig struct {
+	Target         string  `json:"target"`          // 控制目标: "p99"（响应时间P99）或 "inflight"（在途请求数）
+	TargetP99Ms    float64 `json:"target_p99_ms"`   // 目标响应时间P99（毫秒）
+	TargetInFlight int     `json:"target_inflight"` // 目标在途请求数
+	Algorithm      string  `json:"algorithm"`       // 控制算法: "aimd" 或 "pid"
+	IntervalMs     int     `json:"interval_ms"`     // 控制周期（毫秒）
+	StartQPS       int     `json:"start_qps"`       // 初始QPS
+	MinQPS         int     `json:"min_qps"`         // 最小QPS
+	MaxQPS         int     `json:"max_qps"`         // 最大QPS
+	IncreaseQPS    int     `json:"increase_qps"`    // AIMD: 未超过目标时每个周期增加的QPS
+	DecreaseFactor float64 `json:"decrease_factor"` // AIMD: 超过目标时QPS乘以的系数
+	Kp             float64 `json:"kp"`              // PID: 比例系数，误差按目标值归一化
+	Ki             float64 `json:"ki"`              // PID: 积分系数
+	Kd             float64 `json:"kd"`              // PID: 微分系数
+}
+
+// Validate 验证闭环速率控制配置
+func (a *AdaptiveConfig) Validate() error {
+	switch a.Target {
+	case "p99":
+		if a.TargetP99Ms <= 0 {
+			return fmt.Errorf("adaptive.target_p99_ms 必须大于0")
+		}
+	case "inflight":
+		if a.TargetInFlight <= 0 {
+			return fmt.Errorf("adaptive.target_inflight 必须大于0")
+		}
+	default:
+		return fmt.Errorf("无效的 adaptive.target: %s, 必须是 'p99' 或 'inflight'", a.Target)
+	}
+	switch a.Algorithm {
+	case "aimd":
+		if a.IncreaseQPS <= 0 || a.DecreaseFactor <= 0 || a.DecreaseFactor >= 1 {
+			return fmt.Errorf("adaptive.increase_qps 必须大于0，adaptive.decrease_factor 必须在 0-1 之间")
+		}
+	case "pid":
+		if a.Kp < 0 || a.Ki < 0 || a.Kd < 0 {
+			return fmt.Errorf("adaptive 的 kp、ki、kd 不能为负数")
+		}
+	default:
+		return fmt.Errorf("无效的 adaptive.algorithm: %s, 必须是 'aimd' 或 'pid'", a.Algorithm)
+	}
+	if a.IntervalMs <= 0 {
+		return fmt.Errorf("adaptive.interval_ms 必须大于0")
+	}
+	if a.MinQPS <= 0 || a.MaxQPS < a.MinQPS || a.StartQPS < a.MinQPS || a.StartQPS > a.MaxQPS {
+		return fmt.Errorf("adaptive 必须满足 0 < min_qps <= start_qps <= max_qps")
+	}
+	return nil
+}
+
+// GetInterval 获取控制周期
+func (a *AdaptiveConfig) GetInterval() time.Duration {
+	return time.Duration(a.IntervalMs) * time.Millisecond
+}
+
+// SLOMetrics 可用于 SLO 断言的指标及其说明
+var SLOMetrics = map[string]string{
+	"avg_sent_qps":         "平均发送QPS",
+	"avg_completed_qps":    "平均完成QPS",
+	"error_rate":           "业务请求错误率（%）",
+	"latency_p50_ms":       "各业务操作中最大的P50服务时间（ms）",
+	"latency_p99_ms":       "各业务操作中最大的P99服务时间（ms）",
+	"latency_p999_ms":      "各业务操作中最大的P99.9服务时间（ms）",
+	"response_time_p99_ms": "各业务操作中最大的P99响应时间（ms），包含客户端排队延迟",
+	"persistence_p99_ms":   "持久化延迟P99（ms）",
+	"save_delay_errors":    "落盘超时的写入数",
+	"data_loss_rate":       "数据丢失率（%）",
+	"lost_writes":          "丢失的写入数",
+	"business_rule_errors": "业务规则校验失败数",
+}
+
+// SLOConfig 一项 SLO 断言，min 和 max 至少配置一个
+type SLOConfig struct {
+	Metric string   `json:"metric"`        // 指标名称，见 SLOMetrics
+	Min    *float64 `json:"min,omitempty"` // 指标允许的最小值
+	Max    *float64 `json:"max,omitempty"` // 指标允许的最大值
+}
+
+// Validate 验证 SLO 断言配置
+func (s *SLOConfig) Validate() error {
+	if _, ok := SLOMetrics[s.Metric]; !ok {
+		return fmt.Errorf("无效的 slo.metric: %s", s.Metric)
+	}
+	if s.Min == nil && s.Max == nil {
+		return fmt.Errorf("slo %s 必须配置 min 或 max", s.Metric)
+	}
+	if s.Min != nil && s.Max != nil && *s.Min > *s.Max {
+		return fmt.Errorf("slo %s 的 min 不能大于 max", s.Metric)
+	}
+	return nil
+}
+
+// SamplingClockConfig 传感器采样时钟配置
+// 传感器在共享的采样周期边界上同时采样，duplicate_ratio 比例的请求使用周期边界作为时间戳，
+// 其余请求的时间戳在发送前一个周期内均匀分布（微秒精度，互不重复）；
+// QPS模式下重复时间戳的请求大部分在周期边界上集中突发发送，突发请求数取整造成的差额由均匀发送的请求补足
+type SamplingClockConfig struct {
+	Enabled        bool    `json:"enabled"`         // 是否启用采样时钟
+	TickMs         int     `json:"tick_ms"`         // 采样周期（毫秒）
+	DuplicateRatio float64 `json:"duplicate_ratio"` // 时间戳落在周期边界上（即重复）的请求比例
+}
+
+// Validate 验证采样时钟配置
+func (s *SamplingClockConfig) Validate() error {
+	if s.TickMs <= 0 {
+		return fmt.Errorf("sampling_clock.tick_ms 必须大于0")
+	}
+	if s.DuplicateRatio < 0 || s.DuplicateRatio > 1 {
+		return fmt.Errorf("sampling_clock.duplicate_ratio 必须在 0-1 之间")
+	}
+	return nil
+}
+
+// GetTick 获取采样周期
+func (s *SamplingClockConfig) GetTick() time.Duration {
+	return time.Duration(s.TickMs) * time.Millisecond
+}
+
 func New() *Config {
 	c := &Config{
-		ServerURL:      "http://localhost:8080",
-		Duration:       30,
-		Mode:           "qps",
-		QPS:            100,
-		Concurrency:    10,
-		KeyRange:       1000,
-		ReportInterval: 1,
-		MySQLDSN:       "user:password@tcp(localhost:3306)/bench_server?charset=utf8mb4&parseTime=True&loc=Local",
+		ServerURL:       "http://localhost:8080",
+		Duration:        30,
+		Mode:            "qps",
+		QPS:             100,
+		Concurrency:     10,
+		SensorDataRatio: 0.4,
+		SensorRWRatio:   0.3,
+		BatchRWRatio:    0.2,
+		QueryRatio:      0.1,
+		KeyRange:        1000,
+		ReportInterval:  1,
+		PayloadSize:     SizeDistribution{Type: "fixed", Value: 64},
+		BatchSize:       SizeDistribution{Type: "fixed", Value: 10},
+		SamplingClock:   SamplingClockConfig{TickMs: 1000, DuplicateRatio: 0.6},
+		QueryLimit:      100,
+		QueryMaxPages:   3,
+		MySQLDSN:        "user:password@tcp(localhost:3306)/bench_server?charset=utf8mb4&parseTime=True&loc=Local",
+
+		Arrival: ArrivalConfig{Type: "constant", OnSeconds: 1, OffSeconds: 1, PeriodSeconds: 60, Amplitude: 0.5},
+		Search: SearchConfig{
+			StartQPS:        100,
+			MaxQPS:          100000,
+			StepSeconds:     10,
+			DrainSeconds:    5,
+			GrowthFactor:    2,
+			Precision:       0.05,
+			MaxP99Ms:        500,
+			MaxErrorRate:    1,
+			MaxPendingRatio: 0.05,
+		},
+
+		Adaptive: AdaptiveConfig{
+			Target:         "p99",
+			TargetP99Ms:    500,
+			TargetInFlight: 100,
+			Algorithm:      "aimd",
+			IntervalMs:     1000,
+			StartQPS:       100,
+			MinQPS:         1,
+			MaxQPS:         100000,
+			IncreaseQPS:    50,
+			DecreaseFactor: 0.7,
+			Kp:             0.5,
+			Ki:             0.1,
+			Kd:             0.05,
+		},
+
+		PersistenceSLAMs:     1000,
+		PersistenceTimeoutMs: 10000,
+		DeviceStatus: DeviceStatusConfig{
+			Table:            "device_status",
+			DeviceIDColumn:   "device_id",
+			LastValueColumn:  "last_value",
+			AlertCountColumn: "alert_count",
+			UpdatedAtColumn:  "updated_at",
+		},
+		Verifier: VerifierConfig{
+			SampleRate:         0.01,
+			QueueSize:          1000,
+			Workers:            8,
+			MaxOpenConns:       8,
+			MaxIdleConns:       8,
+			ConnMaxLifetimeSec: 300,
+		},
 	}
 	c.calculateDerivedFields()
 	return c
@@ -91,31 +730,184 @@ func (c *Config) SaveToFile(filename string) error {
 }
 
 func (c *Config) calculateDerivedFields() {
+	// 配置了负载阶段时，压测持续时间为各阶段之和
+	if len(c.Stages) > 0 {
+		c.Duration = 0
+		for i := range c.Stages {
+			if c.Stages[i].Ramp == "" {
+				c.Stages[i].Ramp = "step"
+			}
+			c.Duration += c.Stages[i].DurationSeconds
+		}
+	}
 	c.durationTime = time.Duration(c.Duration) * time.Second
 	c.reportIntervalTime = time.Duration(c.ReportInterval) * time.Second
+
+	// 拓扑生成参数未配置的字段使用默认值：3000个工厂，每个工厂20-50个设备、2-8种指标，zipf 负载分布
+	if t := c.Topology; t != nil && len(t.Factories) == 0 {
+		if t.FactoryCount == 0 {
+			t.FactoryCount = 3000
+		}
+		if t.DevicesMin == 0 && t.DevicesMax == 0 {
+			t.DevicesMin, t.DevicesMax = 20, 50
+		}
+		if t.MetricsMin == 0 && t.MetricsMax == 0 {
+			t.MetricsMin, t.MetricsMax = 2, 8
+		}
+		if t.Skew == "" {
+			t.Skew = "zipf"
+		}
+		if t.SkewParam == 0 && t.Skew != "uniform" {
+			t.SkewParam = 1.0
+		}
+	}
+
+	// 向进程发送信号时默认使用 SIGTERM
+	if r := c.Restart; r != nil && r.PID != 0 && r.Signal == "" {
+		r.Signal = "TERM"
+	}
 }
 
 func (c *Config) Validate() error {
 	// 验证模式
-	if c.Mode != "qps" && c.Mode != "concurrency" {
-		return fmt.Errorf("无效的模式: %s, 必须是 'qps' 或 'concurrency'", c.Mode)
+	switch c.Mode {
+	case "qps", "concurrency", "search", "adaptive":
+	default:
+		return fmt.Errorf("无效的模式: %s, 必须是 'qps'、'concurrency'、'search' 或 'adaptive'", c.Mode)
+	}
+
+	// 验证闭环速率控制配置，该模式自行调整QPS，不能与负载阶段同时使用
+	if c.Mode == "adaptive" {
+		if len(c.Stages) > 0 {
+			return fmt.Errorf("adaptive 模式不能配置 stages")
+		}
+		if err := c.Adaptive.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// 验证容量搜索配置，搜索模式自行调整QPS，不能与负载阶段同时使用
+	if c.Mode == "search" {
+		if len(c.Stages) > 0 {
+			return fmt.Errorf("search 模式不能配置 stages")
+		}
+		if err := c.Search.Validate(); err != nil {
+			return err
+		}
+		if probe := c.Search.StepSeconds + c.Search.DrainSeconds; c.Duration < minSearchProbes*probe {
+			return fmt.Errorf("search 模式的 duration_seconds 至少为 %d（%d 次探测，每次 step_seconds + drain_seconds = %d 秒）",
+				minSearchProbes*probe, minSearchProbes, probe)
+		}
+	}
+
+	// 验证到达过程
+	if err := c.Arrival.Validate(); err != nil {
+		return err
+	}
+
+	// 验证负载阶段，配置阶段时不使用 qps 和 concurrency
+	for i := range c.Stages {
+		if err := c.Stages[i].Validate(c.Mode); err != nil {
+			return err
+		}
 	}
 
 	// 验证QPS
-	if c.Mode == "qps" && c.QPS <= 0 {
+	if len(c.Stages) == 0 && c.Mode == "qps" && c.QPS <= 0 {
 		return fmt.Errorf("QPS必须大于0")
 	}
 
 	// 验证并发数
-	if c.Mode == "concurrency" && c.Concurrency <= 0 {
+	if len(c.Stages) == 0 && c.Mode == "concurrency" && c.Concurrency <= 0 {
 		return fmt.Errorf("并发数必须大于0")
 	}
 
+	// 验证操作比例
+	if c.SensorDataRatio < 0 || c.SensorRWRatio < 0 || c.BatchRWRatio < 0 || c.QueryRatio < 0 {
+		return fmt.Errorf("操作比例不能为负数")
+	}
+	totalRatio := c.TotalOperationRatio()
+	if totalRatio <= 0 {
+		return fmt.Errorf("操作比例总和必须大于0")
+	}
+	if totalRatio > 1.0+1e-9 {
+		return fmt.Errorf("操作比例总和不能超过1.0, 当前为 %.2f", totalRatio)
+	}
+
 	// 验证键值范围
 	if c.KeyRange <= 0 {
 		return fmt.Errorf("设备ID范围必须大于0")
 	}
 
+	// 验证拓扑配置
+	if c.Topology != nil {
+		if err := c.Topology.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// 验证采样时钟配置
+	if c.SamplingClock.Enabled {
+		if err := c.SamplingClock.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// 验证负载大小分布，需要写入指纹时负载至少容纳一个指纹
+	minPayloadSize := 0
+	if c.UseFingerprints() {
+		minPayloadSize = fingerprintLen
+		if c.MaxConcurrency() >= maxFingerprintWorkers {
+			return fmt.Errorf("需要写入指纹时并发数必须小于 %d", maxFingerprintWorkers)
+		}
+	}
+	if err := c.PayloadSize.Validate("payload_size", minPayloadSize, maxPayloadSize); err != nil {
+		return err
+	}
+
+	// 验证批量大小分布（接口限制每批1-1000条）
+	if err := c.BatchSize.Validate("batch_size", 1, 1000); err != nil {
+		return err
+	}
+
+	// 验证查询分页配置（接口限制每页1-10000条）
+	if c.QueryLimit < 1 || c.QueryLimit > 10000 {
+		return fmt.Errorf("query_limit 必须在 1-10000 之间")
+	}
+	if c.QueryMaxPages < 1 {
+		return fmt.Errorf("query_max_pages 必须大于0")
+	}
+
+	// 验证持久化探测配置
+	if c.PersistenceSLAMs <= 0 {
+		return fmt.Errorf("persistence_sla_ms 必须大于0")
+	}
+	if c.PersistenceTimeoutMs < c.PersistenceSLAMs {
+		return fmt.Errorf("persistence_timeout_ms 不能小于 persistence_sla_ms")
+	}
+	if err := c.Verifier.Validate(); err != nil {
+		return err
+	}
+	if c.VerifyBusinessRules {
+		if err := c.DeviceStatus.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// 验证崩溃恢复测试配置
+	if c.Restart != nil {
+		if err := c.Restart.Validate(c.Duration); err != nil {
+			return err
+		}
+	}
+
+	// 验证 SLO 断言
+	for i := range c.SLO {
+		if err := c.SLO[i].Validate(); err != nil {
+			return err
+		}
+	}
+
 	if c.ReportKey == "" {
 		return fmt.Errorf("上报密钥不能为空")
 	}
@@ -128,17 +920,108 @@ func (c *Config) Print() {
 	fmt.Printf("服务器地址: %s\n", c.ServerURL)
 	fmt.Printf("测试持续时间: %d 秒\n", c.Duration)
 	fmt.Printf("流量控制模式: %s\n", c.Mode)
-	if c.Mode == "qps" {
+	if len(c.Stages) > 0 {
+		fmt.Printf("负载阶段:\n")
+		for i, stage := range c.Stages {
+			fmt.Printf("  %d. %d 秒, 目标 %d (%s)\n", i+1, stage.DurationSeconds, stage.Target(c.Mode), stage.Ramp)
+		}
+	} else if c.Mode == "search" {
+		fmt.Printf("容量搜索: 从 %d QPS 开始按 %.1f 倍提高(最大 %d)，每次探测 %d 秒(排空 %d 秒)，精度 %.0f%%\n",
+			c.Search.StartQPS, c.Search.GrowthFactor, c.Search.MaxQPS, c.Search.StepSeconds, c.Search.DrainSeconds, c.Search.Precision*100)
+		fmt.Printf("搜索阈值: 响应时间P99 %.0fms, 错误率 %.2f%%, 未完成比例 %.0f%%\n",
+			c.Search.MaxP99Ms, c.Search.MaxErrorRate, c.Search.MaxPendingRatio*100)
+	} else if a := &c.Adaptive; c.Mode == "adaptive" {
+		target := fmt.Sprintf("响应时间P99 %.0fms", a.TargetP99Ms)
+		if a.Target == "inflight" {
+			target = fmt.Sprintf("在途请求数 %d", a.TargetInFlight)
+		}
+		fmt.Printf("闭环速率控制: 目标%s, 算法 %s, 周期 %dms, QPS %d (%d-%d)\n",
+			target, a.Algorithm, a.IntervalMs, a.StartQPS, a.MinQPS, a.MaxQPS)
+	} else if c.Mode == "qps" {
 		fmt.Printf("目标QPS: %d\n", c.QPS)
 	} else {
 		fmt.Printf("并发协程数: %d\n", c.Concurrency)
 	}
+	if c.Mode != "concurrency" {
+		fmt.Printf("到达过程: %s\n", c.Arrival.String())
+	}
+	fmt.Printf("操作比例: 上报=%.2f 读写=%.2f 批量=%.2f 查询=%.2f\n",
+		c.SensorDataRatio, c.SensorRWRatio, c.BatchRWRatio, c.QueryRatio)
+	fmt.Printf("随机种子: %d (运行序号 %d)\n", c.Seed, c.RunIndex)
 	fmt.Printf("设备ID范围: %d\n", c.KeyRange)
-	fmt.Printf("数据大小: 64 字节（固定）\n")
+	fmt.Printf("数据大小(字节): %s\n", c.PayloadSize.String())
+	fmt.Printf("批量大小: %s\n", c.BatchSize.String())
+	fmt.Printf("查询分页: 每页 %d 条, 最多 %d 页\n", c.QueryLimit, c.QueryMaxPages)
+	if c.SamplingClock.Enabled {
+		fmt.Printf("采样时钟: 周期 %dms, 重复时间戳比例 %.0f%%\n",
+			c.SamplingClock.TickMs, c.SamplingClock.DuplicateRatio*100)
+	}
 	fmt.Printf("报告间隔: %d 秒\n", c.ReportInterval)
+	fmt.Printf("持久化时限: %dms (最长探测 %dms)\n", c.PersistenceSLAMs, c.PersistenceTimeoutMs)
+	fmt.Printf("持久化探测: 采样率 %.2f%%, 队列 %d, 校验协程 %d, 连接池 %d/%d\n", c.Verifier.SampleRate*100,
+		c.Verifier.QueueSize, c.Verifier.Workers, c.Verifier.MaxOpenConns, c.Verifier.MaxIdleConns)
+	fmt.Printf("业务规则校验: %v\n", c.VerifyBusinessRules)
+	if c.LedgerFile != "" {
+		fmt.Printf("写入账本: %s\n", c.LedgerFile)
+	}
+	if len(c.SLO) > 0 {
+		fmt.Printf("SLO 断言: %d 项\n", len(c.SLO))
+	}
+	if c.MetricsAddr != "" {
+		fmt.Printf("指标监听地址: %s\n", c.MetricsAddr)
+	}
+	if r := c.Restart; r != nil {
+		if r.Command != "" {
+			fmt.Printf("崩溃恢复测试: 第 %d 秒执行 %q\n", r.OffsetSeconds, r.Command)
+		} else {
+			fmt.Printf("崩溃恢复测试: 第 %d 秒向进程 %d 发送 SIG%s\n", r.OffsetSeconds, r.PID, r.Signal)
+		}
+		if c.LedgerFile == "" {
+			fmt.Printf("警告: 未配置 ledger_file，崩溃恢复测试不统计重启前已确认但丢失的写入\n")
+		}
+	}
 	fmt.Printf("================\n")
 }
 
+// GetPersistenceSLA 获取持久化时限
+func (c *Config) GetPersistenceSLA() time.Duration {
+	return time.Duration(c.PersistenceSLAMs) * time.Millisecond
+}
+
+// GetPersistenceTimeout 获取持久化探测的最长等待时间
+func (c *Config) GetPersistenceTimeout() time.Duration {
+	return time.Duration(c.PersistenceTimeoutMs) * time.Millisecond
+}
+
+// UseFingerprints 判断写入是否需要携带指纹：写入账本、持久化探测和业务规则校验都依赖指纹识别本次运行的写入
+func (c *Config) UseFingerprints() bool {
+	return c.LedgerFile != "" || c.Verifier.SampleRate > 0 || c.VerifyBusinessRules
+}
+
+// ResolveSeed 未指定随机种子时生成一个种子，确保本次运行的种子可被记录和重现
+func (c *Config) ResolveSeed() {
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+}
+
+// MaxConcurrency 获取并发模式下的最大并发数，配置负载阶段时取各阶段的最大值
+func (c *Config) MaxConcurrency() int {
+	if len(c.Stages) == 0 {
+		return c.Concurrency
+	}
+	maxConcurrency := 0
+	for _, stage := range c.Stages {
+		maxConcurrency = max(maxConcurrency, stage.Concurrency)
+	}
+	return maxConcurrency
+}
+
+// TotalOperationRatio 获取操作比例总和
+func (c *Config) TotalOperationRatio() float64 {
+	return c.SensorDataRatio + c.SensorRWRatio + c.BatchRWRatio + c.QueryRatio
+}
+
 // GetDuration 获取持续时间
 func (c *Config) GetDuration() time.Duration {
 	return c.durationTime
@@ -0,0 +1,170 @@
+package ratecontroller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"splay/model"
+)
+
+// adaptiveState 闭环速率控制的调整过程
+type adaptiveState struct {
+	mu         sync.Mutex
+	trajectory []model.AdaptivePoint
+
+	// PID 控制器状态
+	integral  float64
+	lastError float64
+	hasLast   bool
+}
+
+// runAdaptive 闭环速率控制模式：每个控制周期读取最近一个周期的响应时间P99或在途请求数，
+// 按 AIMD 或 PID 调整目标QPS，请求由QPS模式的发送goroutine按目标QPS发送
+func (rc *Controller) runAdaptive(ctx context.Context) {
+	cfg := &rc.config.Adaptive
+	window := rc.statsCollector.NewWindow()
+	ticker := time.NewTicker(cfg.GetInterval())
+	defer ticker.Stop()
+
+	qps := float64(cfg.StartQPS)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ws := window.Next()
+		measured, ok := rc.adaptiveMeasure(ws.Completed, ws.Pending, ws.ResponseTime.P99)
+		if ok {
+			qps = rc.adjustQPS(qps, measured, ws.Elapsed)
+			qps = math.Min(math.Max(qps, float64(cfg.MinQPS)), float64(cfg.MaxQPS))
+			rc.qps.Store(int64(math.Round(qps)))
+		}
+
+		completedQPS := 0.0
+		if ws.Elapsed > 0 {
+			completedQPS = float64(ws.Completed) / ws.Elapsed.Seconds()
+		}
+		point := model.AdaptivePoint{
+			Elapsed:      float32(rc.statsCollector.Elapsed().Seconds()),
+			Qps:          int64(math.Round(qps)),
+			CompletedQPS: float32(completedQPS),
+		}
+		if ok && !math.IsInf(measured, 0) {
+			m := float32(measured)
+			point.Measured = &m
+		}
+		rc.adaptive.mu.Lock()
+		rc.adaptive.trajectory = append(rc.adaptive.trajectory, point)
+		rc.adaptive.mu.Unlock()
+	}
+}
+
+// adaptiveTarget 获取控制目标值
+func (rc *Controller) adaptiveTarget() float64 {
+	if rc.config.Adaptive.Target == "inflight" {
+		return float64(rc.config.Adaptive.TargetInFlight)
+	}
+	return rc.config.Adaptive.TargetP99Ms
+}
+
+// adaptiveMeasure 获取本周期的被控量，没有可用的测量值时返回 false
+// 以P99为目标时，周期内没有完成的请求但仍有在途请求说明服务端停顿，视为无穷大
+func (rc *Controller) adaptiveMeasure(completed, pending int64, p99 float64) (float64, bool) {
+	if rc.config.Adaptive.Target == "inflight" {
+		return float64(pending), true
+	}
+	switch {
+	case completed > 0:
+		return p99, true
+	case pending > 0:
+		return math.Inf(1), true
+	}
+	return 0, false
+}
+
+// adjustQPS 根据被控量计算新的目标QPS
+func (rc *Controller) adjustQPS(qps, measured float64, elapsed time.Duration) float64 {
+	cfg := &rc.config.Adaptive
+	target := rc.adaptiveTarget()
+
+	if cfg.Algorithm == "aimd" {
+		if measured <= target {
+			return qps + float64(cfg.IncreaseQPS)
+		}
+		return qps * cfg.DecreaseFactor
+	}
+
+	// PID: 误差按目标值归一化到 [-1, 1]，输出作为QPS的相对调整量，限制在 ±50% 以内
+	e := math.Max(math.Min((target-measured)/target, 1), -1)
+	dt := elapsed.Seconds()
+	state := &rc.adaptive
+	state.integral = math.Max(math.Min(state.integral+e*dt, 10), -10) // 限制积分项，避免长时间饱和后超调
+	derivative := 0.0
+	if state.hasLast && dt > 0 {
+		derivative = (e - state.lastError) / dt
+	}
+	state.lastError, state.hasLast = e, true
+
+	output := cfg.Kp*e + cfg.Ki*state.integral + cfg.Kd*derivative
+	return qps * (1 + math.Max(math.Min(output, 0.5), -0.5))
+}
+
+// AdaptiveResult 获取闭环速率控制的结果，非 adaptive 模式返回 nil
+// 稳态值取后一半控制周期的平均值，即服务端在目标延迟或在途请求数下能提供的吞吐量
+func (rc *Controller) AdaptiveResult() *model.AdaptiveResult {
+	if rc.config.Mode != "adaptive" {
+		return nil
+	}
+
+	rc.adaptive.mu.Lock()
+	defer rc.adaptive.mu.Unlock()
+	result := &model.AdaptiveResult{
+		Target:      rc.config.Adaptive.Target,
+		TargetValue: float32(rc.adaptiveTarget()),
+		Algorithm:   rc.config.Adaptive.Algorithm,
+		Trajectory:  append([]model.AdaptivePoint{}, rc.adaptive.trajectory...),
+	}
+
+	steady := rc.adaptive.trajectory[len(rc.adaptive.trajectory)/2:]
+	var qps, completedQPS, measured float64
+	var measuredCount int
+	for _, p := range steady {
+		qps += float64(p.Qps)
+		completedQPS += float64(p.CompletedQPS)
+		if p.Measured != nil {
+			measured += float64(*p.Measured)
+			measuredCount++
+		}
+	}
+	if n := len(steady); n > 0 {
+		result.SteadyQPS = float32(qps / float64(n))
+		result.SteadyCompletedQPS = float32(completedQPS / float64(n))
+	}
+	if measuredCount > 0 {
+		result.SteadyMeasured = float32(measured / float64(measuredCount))
+	}
+	return result
+}
+
+// PrintAdaptiveResult 打印闭环速率控制的结果，非 adaptive 模式不输出
+func (rc *Controller) PrintAdaptiveResult() {
+	result := rc.AdaptiveResult()
+	if result == nil {
+		return
+	}
+
+	unit := "ms"
+	if result.Target == "inflight" {
+		unit = ""
+	}
+	fmt.Printf("\n=== 闭环速率控制 ===\n")
+	fmt.Printf("目标: %s = %.1f%s (%s, %d 个控制周期)\n",
+		result.Target, result.TargetValue, unit, result.Algorithm, len(result.Trajectory))
+	fmt.Printf("稳态(后一半周期平均): 发送QPS %.1f, 完成QPS %.1f, %s %.1f%s\n",
+		result.SteadyQPS, result.SteadyCompletedQPS, result.Target, result.SteadyMeasured, unit)
+}
@@ -0,0 +1,114 @@
+package ratecontroller
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"splay/model"
+	"splay/pkg/config"
+	"splay/pkg/stats"
+)
+
+// arrivalProcess 按配置的到达过程计算下一个请求的计划发送时刻
+// 发送时刻以距调度起点的秒数表示，onoff 和 diurnal 的相位也从起点计算，避免逐个间隔按纳秒取整累积误差
+type arrivalProcess struct {
+	config *config.ArrivalConfig
+}
+
+// next 计算 prev 之后下一个请求的计划发送时刻，rate 为平均速率（每秒请求数）
+// 速率为0时返回一个空闲间隔后的时刻，send 为 false 表示该时刻只用于检查速率变化、不发送请求
+func (a *arrivalProcess) next(prev, rate float64, rng *rand.Rand) (float64, bool) {
+	if rate <= 0 {
+		return prev + idleInterval.Seconds(), false
+	}
+
+	switch a.config.Type {
+	case "poisson":
+		return prev + rng.ExpFloat64()/rate, true
+	case "onoff":
+		// 发送阶段的速率放大为 (on+off)/on 倍，保证周期内的平均速率不变
+		period := a.config.OnSeconds + a.config.OffSeconds
+		t := prev + a.config.OnSeconds/period/rate
+		if phase := math.Mod(t, period); phase >= a.config.OnSeconds {
+			t += period - phase
+		}
+		return t, true
+	case "diurnal":
+		phase := 2 * math.Pi * prev / a.config.PeriodSeconds
+		return prev + 1/(rate*(1+a.config.Amplitude*math.Sin(phase))), true
+	}
+	return prev + 1/rate, true
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// arrivalStats 统计实际产生的请求到达间隔，均匀发送和突发发送的请求合并为一个到达序列
+type arrivalStats struct {
+	mu        sync.Mutex
+	last      time.Time
+	count     int64
+	sum       float64 // 间隔之和（秒）
+	sumSquare float64 // 间隔平方和，用于计算变异系数
+	histogram *stats.Histogram
+}
+
+// record 记录一个请求的实际发送时刻
+func (s *arrivalStats) record(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.last.IsZero() {
+		gap := max(t.Sub(s.last), 0)
+		s.count++
+		s.sum += gap.Seconds()
+		s.sumSquare += gap.Seconds() * gap.Seconds()
+		s.histogram.Record(gap)
+	}
+	if t.After(s.last) {
+		s.last = t
+	}
+}
+
+// ArrivalStats 获取实际产生的请求到达间隔分布，并发模式返回 nil
+func (rc *Controller) ArrivalStats() *model.ArrivalStats {
+	if rc.config.Mode == "concurrency" {
+		return nil
+	}
+
+	s := &rc.arrivalStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &model.ArrivalStats{
+		Type:        rc.config.Arrival.Type,
+		Intervals:   s.count,
+		Percentiles: *s.histogram.GetPercentiles().ToModel(),
+	}
+	if s.count > 0 && s.sum > 0 {
+		mean := s.sum / float64(s.count)
+		variance := max(s.sumSquare/float64(s.count)-mean*mean, 0)
+		result.MeanIntervalMs = float32(mean * 1000)
+		result.ActualQPS = float32(1 / mean)
+		result.Cv = float32(math.Sqrt(variance) / mean)
+	}
+	return result
+}
+
+// PrintArrivalStats 打印实际产生的请求到达间隔分布，并发模式不输出
+func (rc *Controller) PrintArrivalStats() {
+	r := rc.ArrivalStats()
+	if r == nil {
+		return
+	}
+
+	fmt.Printf("\n=== 到达过程 ===\n")
+	fmt.Printf("类型: %s, 到达间隔数: %d, 平均间隔 %.3fms (实际QPS %.1f), 变异系数 %.2f\n",
+		rc.config.Arrival.String(), r.Intervals, r.MeanIntervalMs, r.ActualQPS, r.Cv)
+	fmt.Printf("间隔分布(ms): P50=%.3f, P90=%.3f, P99=%.3f, P99.9=%.3f\n",
+		r.Percentiles.P50, r.Percentiles.P90, r.Percentiles.P99, r.Percentiles.P999)
+}
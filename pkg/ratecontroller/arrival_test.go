@@ -0,0 +1,78 @@
+package ratecontroller
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"splay/pkg/config"
+)
+
+// TestArrivalMeanRate 各到达过程在整数个周期内的平均速率等于目标速率
+func TestArrivalMeanRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		arrival   config.ArrivalConfig
+		rate      float64
+		seconds   float64 // 模拟时长，onoff 和 diurnal 取周期的整数倍
+		tolerance float64 // 平均速率允许的相对误差
+	}{
+		{"均匀", config.ArrivalConfig{Type: "constant"}, 1000, 100, 0.001},
+		{"均匀-非整数间隔", config.ArrivalConfig{Type: "constant"}, 333, 100, 0.001},
+		{"泊松", config.ArrivalConfig{Type: "poisson"}, 1000, 100, 0.01},
+		{"开关突发", config.ArrivalConfig{Type: "onoff", OnSeconds: 0.5, OffSeconds: 1.5}, 1000, 100, 0.001},
+		{"正弦", config.ArrivalConfig{Type: "diurnal", PeriodSeconds: 10, Amplitude: 0.5}, 1000, 100, 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := arrivalProcess{config: &tt.arrival}
+			rng := rand.New(rand.NewSource(1))
+
+			count := 0
+			for offset, send := 0.0, false; offset < tt.seconds; {
+				if send {
+					count++
+				}
+				offset, send = a.next(offset, tt.rate, rng)
+			}
+
+			got := float64(count) / tt.seconds
+			if math.Abs(got-tt.rate)/tt.rate > tt.tolerance {
+				t.Errorf("平均速率 = %.2f, want %.2f (±%.1f%%)", got, tt.rate, tt.tolerance*100)
+			}
+		})
+	}
+}
+
+// TestArrivalOnOffSilence 开关突发的停止阶段不发送请求
+func TestArrivalOnOffSilence(t *testing.T) {
+	a := arrivalProcess{config: &config.ArrivalConfig{Type: "onoff", OnSeconds: 1, OffSeconds: 2}}
+	rng := rand.New(rand.NewSource(1))
+
+	for offset := 0.0; offset < 30; {
+		next, send := a.next(offset, 500, rng)
+		if !send {
+			t.Fatalf("速率大于0时 next 返回 send=false")
+		}
+		if phase := math.Mod(next, 3); phase >= 1 {
+			t.Fatalf("计划发送时刻 %.4fs 位于停止阶段（周期内相位 %.4fs）", next, phase)
+		}
+		offset = next
+	}
+}
+
+// TestArrivalZeroRate 目标速率为0时只按空闲间隔检查速率变化，不发送请求
+func TestArrivalZeroRate(t *testing.T) {
+	for _, typ := range []string{"constant", "poisson", "onoff", "diurnal"} {
+		t.Run(typ, func(t *testing.T) {
+			a := arrivalProcess{config: &config.ArrivalConfig{Type: typ, OnSeconds: 1, OffSeconds: 1, PeriodSeconds: 60, Amplitude: 0.5}}
+			next, send := a.next(5, 0, rand.New(rand.NewSource(1)))
+			if send {
+				t.Errorf("速率为0时 send = true")
+			}
+			if want := 5 + idleInterval.Seconds(); math.Abs(next-want) > 1e-9 {
+				t.Errorf("next = %.4f, want %.4f", next, want)
+			}
+		})
+	}
+}
@@ -8,7 +8,12 @@
 // 5. 优雅停止: 支持上下文取消和优雅停止机制
 // 6. 运行时配置: 支持运行时调整操作比例配置
 // 7. 状态监控: 提供运行状态等监控信息
-// 8. 精确速率控制: 使用ticker实现精确的QPS控制
+// 8. 精确速率控制: QPS模式下由单个调度器按计划发送时刻发送请求，被延迟调度时补发，并统计调度延迟和实际发送速率
+// 9. 协调遗漏修正: QPS模式下把每个请求的计划发送时刻传给Worker，响应时间从计划发送时刻计算
+// 10. 负载阶段: 按配置的阶段依次调整目标QPS或并发数，支持阶跃、线性和指数变化，统计按阶段切分
+// 11. 容量搜索: 按倍数提高QPS直到P99、错误率或未完成请求比例超过阈值，再二分查找最高可持续QPS
+// 12. 闭环速率控制: 按最近一个周期的响应时间P99或在途请求数，用 AIMD 或 PID 调整QPS使其稳定在目标值
+// 13. 到达过程: QPS模式下请求按均匀、泊松、开关突发或正弦变化的到达过程发送，长期平均速率等于目标QPS，并统计实际产生的到达间隔分布
 //
 // 设计原则:
 // - QPS模式: 每个请求独立goroutine，按固定速率创建
@@ -20,10 +25,14 @@ package ratecontroller
 
 import (
 	"context"
+	"math"
+	"math/rand"
 	"splay/client"
 	"splay/pkg/config"
 	"splay/pkg/stats"
+	"splay/pkg/verifier"
 	"splay/pkg/worker"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,22 +41,72 @@ type Controller struct {
 	config         *config.Config
 	statsCollector *stats.Collector
 	httpClient     *client.ClientWithResponses
+	topology       *worker.Topology
+	tracker        *worker.WriteTracker // 所有Worker共享的写入跟踪器
+	verifier       *verifier.Verifier
+
+	// 当前的目标QPS和并发数，配置负载阶段时随阶段变化
+	qps         atomic.Int64
+	concurrency atomic.Int64
+
+	// 搜索模式的进度和结果，搜索完成时关闭 done
+	search searchState
+	done   chan struct{}
+
+	// 闭环速率控制的调整过程
+	adaptive adaptiveState
+
+	// QPS模式下请求的到达过程、实际到达间隔和调度延迟统计
+	arrival      arrivalProcess
+	arrivalStats arrivalStats
+	pacerStats   pacerStats
 }
 
-func New(cfg *config.Config, statsCollector *stats.Collector, httpClient *client.ClientWithResponses) *Controller {
-	return &Controller{
+const (
+	qpsWorkers      = 32                     // QPS模式下轮流执行请求的Worker数，分散各Worker随机数源的锁竞争
+	idleInterval    = 100 * time.Millisecond // 目标QPS为0或Worker空闲时检查目标值变化的间隔
+	profileInterval = 100 * time.Millisecond // 线性和指数变化的负载阶段调整目标值的间隔
+)
+
+func New(cfg *config.Config, statsCollector *stats.Collector, httpClient *client.ClientWithResponses,
+	topology *worker.Topology, v *verifier.Verifier) *Controller {
+	rc := &Controller{
 		config:         cfg,
 		statsCollector: statsCollector,
 		httpClient:     httpClient,
+		topology:       topology,
+		tracker:        worker.NewWriteTracker(),
+		verifier:       v,
+		done:           make(chan struct{}),
+		arrival:        arrivalProcess{config: &cfg.Arrival},
 	}
+	rc.arrivalStats.histogram = stats.NewHistogram()
+	rc.pacerStats.histogram = stats.NewHistogram()
+	rc.qps.Store(int64(cfg.QPS))
+	rc.concurrency.Store(int64(cfg.Concurrency))
+	return rc
 }
 
 // Start 启动流量控制器
 func (rc *Controller) Start(ctx context.Context) {
+	if len(rc.config.Stages) > 0 {
+		// 先设置第一个阶段的初始目标值，避免发送goroutine启动时读到配置中的 qps 或 concurrency
+		first := rc.config.Stages[0]
+		rc.setTarget(int64(math.Round(rampValue(first.Ramp, 0, float64(first.Target(rc.config.Mode)), 0))))
+		go rc.runStages(ctx)
+	}
 
 	switch rc.config.Mode {
 	case "qps":
 		go rc.runQPSMode(ctx)
+	case "search":
+		rc.qps.Store(int64(rc.config.Search.StartQPS))
+		go rc.runQPSMode(ctx)
+		go rc.runSearch(ctx)
+	case "adaptive":
+		rc.qps.Store(int64(rc.config.Adaptive.StartQPS))
+		go rc.runQPSMode(ctx)
+		go rc.runAdaptive(ctx)
 	case "concurrency":
 		go rc.runConcurrencyMode(ctx)
 	default:
@@ -55,52 +114,81 @@ func (rc *Controller) Start(ctx context.Context) {
 	}
 }
 
-// runQPSMode QPS模式：按固定速率创建独立的goroutine执行请求
+// runQPSMode QPS模式：按目标速率创建独立的goroutine执行请求
+// 启用采样时钟时，duplicate_ratio 比例的请求在采样周期边界上集中突发发送，其余请求均匀发送
 func (rc *Controller) runQPSMode(ctx context.Context) {
 
-	if rc.config.QPS <= 0 {
+	if len(rc.config.Stages) == 0 && rc.qps.Load() <= 0 {
 		return
 	}
 
-	interval := time.Duration(1000000000 / rc.config.QPS * 32) // 纳秒
+	rc.runPacer(ctx)
+}
 
-	for i := 0; i < 32; i++ {
-		go func() {
-			ticker := time.NewTicker(interval)
-			defer ticker.Stop()
-			w := worker.New(0, rc.httpClient, rc.statsCollector, rc.config)
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-ticker.C:
-					go func() {
-						w.ExecuteOperation()
-					}()
-				}
-			}
-		}()
+// dispatch 在独立的goroutine中执行一个请求，并记录实际发送时刻用于统计到达间隔和调度延迟
+// tick 为重复时间戳请求（突发请求和抽中的均匀发送请求）所属的采样时刻，请求写入的时间戳使用该时刻；其他请求为零值
+func (rc *Controller) dispatch(w *worker.Worker, scheduled, tick time.Time) {
+	now := time.Now()
+	rc.arrivalStats.record(now)
+	rc.pacerStats.record(now, scheduled)
+	opType := rc.selectOperationType(w.Rand())
+	go func() {
+		w.ExecuteOperation(opType, scheduled, tick)
+	}()
+}
+
+// splitQPS 将目标QPS拆分为均匀发送的速率和每个采样周期边界上突发发送的请求数
+func (rc *Controller) splitQPS(qps int64) (float64, int64) {
+	clock := &rc.config.SamplingClock
+	if !clock.Enabled {
+		return float64(qps), 0
 	}
-	<-ctx.Done()
+	burstSize := int64(math.Round(float64(qps) * clock.DuplicateRatio * float64(clock.TickMs) / 1000))
+	return max(float64(qps)-float64(burstSize)*1000/float64(clock.TickMs), 0), burstSize
+}
+
+// regularDuplicateRatio 均匀发送的请求中使用周期边界作为时间戳的比例
+// 突发请求都使用周期边界，突发请求数取整后占比与 duplicate_ratio 的差额由均匀发送的请求补足，使总体比例等于 duplicate_ratio
+func (rc *Controller) regularDuplicateRatio(qps int64) float64 {
+	clock := &rc.config.SamplingClock
+	if !clock.Enabled || qps <= 0 {
+		return 0
+	}
+	_, burstSize := rc.splitQPS(qps)
+	burstShare := min(float64(burstSize)*1000/float64(clock.TickMs)/float64(qps), 1)
+	if burstShare >= 1 {
+		return 0
+	}
+	return min(max((clock.DuplicateRatio-burstShare)/(1-burstShare), 0), 1)
 }
 
-// runConcurrencyMode 并发模式：维持固定数量的worker goroutine
+// runConcurrencyMode 并发模式：维持目标数量的worker goroutine
+// 按最大并发数启动worker，序号不小于当前目标并发数的worker空闲等待
 func (rc *Controller) runConcurrencyMode(ctx context.Context) {
 
-	if rc.config.Concurrency <= 0 {
+	maxConcurrency := rc.config.MaxConcurrency()
+	if maxConcurrency <= 0 {
 		return
 	}
 
 	// 启动固定数量的worker goroutine
-	for i := 0; i < rc.config.Concurrency; i++ {
+	for i := 0; i < maxConcurrency; i++ {
 		go func(workerID int) {
-			w := worker.New(workerID, rc.httpClient, rc.statsCollector, rc.config)
+			w := worker.New(workerID, rc.httpClient, rc.statsCollector, rc.config, rc.topology, rc.tracker, rc.verifier)
 			for {
+				if int64(workerID) >= rc.concurrency.Load() {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(idleInterval):
+					}
+					continue
+				}
 				select {
 				case <-ctx.Done():
 					return
 				default:
-					w.ExecuteOperation()
+					w.ExecuteOperation(rc.selectOperationType(w.Rand()), time.Time{}, time.Time{})
 				}
 			}
 		}(i)
@@ -109,7 +197,75 @@ func (rc *Controller) runConcurrencyMode(ctx context.Context) {
 	<-ctx.Done()
 }
 
-// selectOperationType 根据配置的比例选择操作类型
-func (rc *Controller) selectOperationType() string {
+// setTarget 设置当前模式下的目标QPS或并发数
+func (rc *Controller) setTarget(target int64) {
+	if rc.config.Mode == "concurrency" {
+		rc.concurrency.Store(target)
+	} else {
+		rc.qps.Store(target)
+	}
+}
+
+// runStages 按负载阶段依次调整目标值，并在阶段边界切分统计
+func (rc *Controller) runStages(ctx context.Context) {
+	defer rc.statsCollector.EndStages()
+
+	var previous float64
+	for i, stage := range rc.config.Stages {
+		target := float64(stage.Target(rc.config.Mode))
+		rc.statsCollector.BeginStage(i, stage.Ramp, int(target))
+
+		start := time.Now()
+		duration := stage.GetDuration()
+		ticker := time.NewTicker(profileInterval)
+		for elapsed := time.Duration(0); elapsed < duration; elapsed = time.Since(start) {
+			progress := elapsed.Seconds() / duration.Seconds()
+			rc.setTarget(int64(math.Round(rampValue(stage.Ramp, previous, target, progress))))
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+			}
+		}
+		ticker.Stop()
+		previous = target
+	}
+	<-ctx.Done()
+}
+
+// rampValue 计算阶段进度 progress（0-1）时的目标值，from 为上一阶段的目标值
+func rampValue(ramp string, from, to, progress float64) float64 {
+	switch ramp {
+	case "linear":
+		return from + (to-from)*progress
+	case "exponential":
+		// 从0开始的指数变化以1为起点
+		from = max(from, 1)
+		return from * math.Pow(to/from, progress)
+	}
+	return to
+}
+
+// selectOperationType 根据配置的比例选择操作类型，使用Worker的随机数流保证可重现
+func (rc *Controller) selectOperationType(rng *rand.Rand) string {
+	// 比例总和可以小于1.0，按各操作比例在总和内加权选择
+	r := rng.Float64() * rc.config.TotalOperationRatio()
+
+	cumulative := rc.config.SensorDataRatio
+	if r < cumulative {
+		return "sensor-data"
+	}
+	cumulative += rc.config.SensorRWRatio
+	if r < cumulative {
+		return "sensor-rw"
+	}
+	cumulative += rc.config.BatchRWRatio
+	if r < cumulative {
+		return "batch-rw"
+	}
+	if rc.config.QueryRatio > 0 {
+		return "query"
+	}
 	return "sensor-data"
 }
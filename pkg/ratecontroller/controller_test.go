@@ -0,0 +1,41 @@
+package ratecontroller
+
+import (
+	"math"
+	"testing"
+
+	"splay/pkg/config"
+)
+
+// TestDuplicateShare QPS模式下突发请求与均匀发送中抽中的请求合计占比等于 duplicate_ratio
+func TestDuplicateShare(t *testing.T) {
+	tests := []struct {
+		name   string
+		qps    int64
+		tickMs int
+		ratio  float64
+	}{
+		{"突发请求数为整数", 1000, 1000, 0.6},
+		{"突发请求数取整偏小", 7, 100, 0.6},
+		{"突发请求数取整为0", 3, 100, 0.6},
+		{"突发请求数取整偏大", 13, 100, 0.5},
+		{"全部重复", 100, 1000, 1},
+		{"没有重复", 100, 1000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.SamplingClock = config.SamplingClockConfig{Enabled: true, TickMs: tt.tickMs, DuplicateRatio: tt.ratio}
+			rc := &Controller{config: cfg}
+
+			regular, burstSize := rc.splitQPS(tt.qps)
+			burstRate := float64(burstSize) * 1000 / float64(tt.tickMs)
+			got := (burstRate + regular*rc.regularDuplicateRatio(tt.qps)) / (burstRate + regular)
+			// 突发请求数取整偏大时均匀发送的请求无法抵消，合计比例只能尽量接近
+			want := max(tt.ratio, burstRate/(burstRate+regular))
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("重复时间戳比例 = %.4f, want %.4f", got, want)
+			}
+		})
+	}
+}
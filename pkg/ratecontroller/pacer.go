@@ -0,0 +1,190 @@
+package ratecontroller
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"splay/model"
+	"splay/pkg/stats"
+	"splay/pkg/worker"
+)
+
+// runPacer QPS模式的调度器：单个goroutine按到达过程计算每个请求的计划发送时刻，到时后发送
+//
+// 计划发送时刻以浮点秒数从起点累加，任意QPS下都不会因整数取整改变速率；
+// 定时器唤醒晚于计划时刻时（定时器精度、调度延迟、GC停顿），立即补发所有已到时刻的请求，保持开环的到达速率，
+// 每个请求实际发送时刻与计划时刻之差记为调度延迟。请求轮流交给 qpsWorkers 个Worker执行
+func (rc *Controller) runPacer(ctx context.Context) {
+	workers := make([]*worker.Worker, qpsWorkers)
+	for i := range workers {
+		workers[i] = worker.New(i, rc.httpClient, rc.statsCollector, rc.config, rc.topology, rc.tracker, rc.verifier)
+	}
+	// 突发请求使用与均匀发送不同的Worker ID，保证随机数流独立
+	burstWorker := worker.New(qpsWorkers, rc.httpClient, rc.statsCollector, rc.config, rc.topology, rc.tracker, rc.verifier)
+	rng := worker.NewArrivalRand(rc.config.Seed)
+
+	start := time.Now()
+	rc.pacerStats.begin(start)
+
+	// 启用采样时钟时，在每个采样周期边界上集中发送一批请求，模拟传感器同时采样后的突发上报，
+	// 这些请求以该周期边界作为时间戳；突发请求数取整造成的重复比例差额由均匀发送的请求按概率补足
+	clock := &rc.config.SamplingClock
+	var tick time.Duration
+	var nextBurst time.Time
+	if clock.Enabled {
+		tick = clock.GetTick()
+		nextBurst = start.Truncate(tick).Add(tick)
+	}
+
+	regular := &pacerSchedule{start: start}
+	sent := 0
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		now := time.Now()
+		rc.pacerStats.advance(now, rc.qps.Load())
+
+		regular.catchUp(now, &rc.arrival, rng, func() float64 {
+			rate, _ := rc.splitQPS(rc.qps.Load())
+			return rate
+		}, func(scheduled time.Time) {
+			var sampled time.Time
+			if clock.Enabled && rng.Float64() < rc.regularDuplicateRatio(rc.qps.Load()) {
+				sampled = scheduled.Truncate(tick)
+			}
+			rc.dispatch(workers[sent%qpsWorkers], scheduled, sampled)
+			sent++
+		})
+		for clock.Enabled && !nextBurst.After(now) {
+			_, burstSize := rc.splitQPS(rc.qps.Load())
+			for range burstSize {
+				rc.dispatch(burstWorker, nextBurst, nextBurst)
+			}
+			nextBurst = nextBurst.Add(tick)
+		}
+
+		wake := regular.next()
+		if clock.Enabled && nextBurst.Before(wake) {
+			wake = nextBurst
+		}
+		timer.Reset(time.Until(wake))
+	}
+}
+
+// pacerSchedule 均匀发送请求的计划发送时刻，以浮点秒数从起点累加
+type pacerSchedule struct {
+	start  time.Time
+	offset float64 // 下一个计划时刻距起点的秒数
+	send   bool    // 下一个计划时刻是否发送请求，速率为0时只是下一次检查速率的时刻；初始为 false，第一次唤醒只读取目标速率
+}
+
+// catchUp 依次处理不晚于 now 的全部计划时刻：唤醒晚于计划时刻时补发所有已到时刻的请求，计划时刻保持不变
+// rate 返回当前的均匀发送速率，每个计划时刻之后重新读取；send 发送一个计划时刻的请求
+func (p *pacerSchedule) catchUp(now time.Time, arrival *arrivalProcess, rng *rand.Rand,
+	rate func() float64, send func(scheduled time.Time)) {
+	for scheduled := p.next(); !scheduled.After(now); scheduled = p.next() {
+		if p.send {
+			send(scheduled)
+		}
+		p.offset, p.send = arrival.next(p.offset, rate(), rng)
+	}
+}
+
+// next 获取下一个计划时刻
+func (p *pacerSchedule) next() time.Time {
+	return p.start.Add(secondsToDuration(p.offset))
+}
+
+// pacerStats 统计调度延迟，以及按目标速率应发送和实际发送的请求数
+type pacerStats struct {
+	mu         sync.Mutex
+	start      time.Time
+	last       time.Time // 最近一次唤醒的时刻
+	expected   float64   // 目标QPS对时间的积分，即按目标速率应发送的请求数
+	dispatched int64
+	lastSent   time.Time // 最近一个请求的实际发送时刻，补发可能晚于最近一次唤醒
+	lagSum     time.Duration
+	maxLag     time.Duration
+	histogram  *stats.Histogram
+}
+
+func (s *pacerStats) begin(start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = start
+	s.last = start
+}
+
+// advance 把上次唤醒以来的时间按当前目标QPS计入应发送的请求数
+func (s *pacerStats) advance(now time.Time, qps int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expected += float64(qps) * now.Sub(s.last).Seconds()
+	s.last = now
+}
+
+// record 记录一个请求的实际发送时刻和调度延迟
+func (s *pacerStats) record(now, scheduled time.Time) {
+	lag := max(now.Sub(scheduled), 0)
+	s.histogram.Record(lag)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dispatched++
+	s.lastSent = now
+	s.lagSum += lag
+	s.maxLag = max(s.maxLag, lag)
+}
+
+// SchedulerStats 获取QPS模式调度器的调度延迟和实际发送速率，并发模式返回 nil
+func (rc *Controller) SchedulerStats() *model.SchedulerStats {
+	if rc.config.Mode == "concurrency" {
+		return nil
+	}
+
+	s := &rc.pacerStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &model.SchedulerStats{
+		Dispatched: s.dispatched,
+		MaxLagMs:   float32(s.maxLag.Seconds() * 1000),
+		Lag:        *s.histogram.GetPercentiles().ToModel(),
+	}
+	if elapsed := s.last.Sub(s.start).Seconds(); elapsed > 0 {
+		result.TargetQPS = float32(s.expected / elapsed)
+	}
+	if elapsed := s.lastSent.Sub(s.start).Seconds(); elapsed > 0 {
+		result.AchievedQPS = float32(float64(s.dispatched) / elapsed)
+	}
+	if s.dispatched > 0 {
+		result.MeanLagMs = float32(s.lagSum.Seconds() * 1000 / float64(s.dispatched))
+	}
+	return result
+}
+
+// PrintSchedulerStats 打印QPS模式调度器的调度延迟和实际发送速率，并发模式不输出
+func (rc *Controller) PrintSchedulerStats() {
+	r := rc.SchedulerStats()
+	if r == nil {
+		return
+	}
+
+	fmt.Printf("\n=== 调度器 ===\n")
+	fmt.Printf("发送请求数: %d, 目标QPS %.1f, 实际QPS %.1f", r.Dispatched, r.TargetQPS, r.AchievedQPS)
+	if r.TargetQPS > 0 {
+		fmt.Printf(" (%.2f%%)", float64(r.AchievedQPS/r.TargetQPS)*100)
+	}
+	fmt.Println()
+	fmt.Printf("调度延迟(ms): 平均=%.3f, P50=%.3f, P99=%.3f, P99.9=%.3f, 最大=%.3f\n",
+		r.MeanLagMs, r.Lag.P50, r.Lag.P99, r.Lag.P999, r.MaxLagMs)
+}
@@ -0,0 +1,81 @@
+package ratecontroller
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"splay/pkg/config"
+)
+
+// TestPacerCatchUp 唤醒晚于计划时刻时补发所有已到时刻的请求，计划时刻不因唤醒延迟而后移
+func TestPacerCatchUp(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	arrival := &arrivalProcess{config: &config.ArrivalConfig{Type: "constant"}}
+	rng := rand.New(rand.NewSource(1))
+	p := &pacerSchedule{start: start}
+
+	rate := 100.0
+	var scheduled []time.Time
+	wake := func(now time.Time) int {
+		before := len(scheduled)
+		p.catchUp(now, arrival, rng, func() float64 { return rate }, func(s time.Time) {
+			if s.After(now) {
+				t.Fatalf("计划时刻 %v 晚于唤醒时刻 %v", s, now)
+			}
+			scheduled = append(scheduled, s)
+		})
+		return len(scheduled) - before
+	}
+
+	// 第一次唤醒只读取目标速率
+	if n := wake(start); n != 0 {
+		t.Fatalf("第一次唤醒发送了 %d 个请求", n)
+	}
+	if want := start.Add(10 * time.Millisecond); !p.next().Equal(want) {
+		t.Fatalf("下一个计划时刻 = %v, want %v", p.next(), want)
+	}
+
+	// 唤醒晚了约1秒，一次补发100个请求，计划时刻仍按10ms的间隔排列
+	if n := wake(start.Add(1005 * time.Millisecond)); n != 100 {
+		t.Fatalf("补发 %d 个请求, want 100", n)
+	}
+	for i, s := range scheduled {
+		want := start.Add(time.Duration(i+1) * 10 * time.Millisecond)
+		if d := s.Sub(want); d < -time.Microsecond || d > time.Microsecond {
+			t.Fatalf("第 %d 个请求的计划时刻 = %v, want %v", i, s, want)
+		}
+	}
+
+	// 未到计划时刻时不发送
+	if n := wake(start.Add(1008 * time.Millisecond)); n != 0 {
+		t.Fatalf("未到计划时刻时发送了 %d 个请求", n)
+	}
+	if n := wake(start.Add(1012 * time.Millisecond)); n != 1 {
+		t.Fatalf("到时后发送 %d 个请求, want 1", n)
+	}
+
+	// 速率变化后的计划时刻按新速率计算：1020ms 已按原速率排定，之后每1ms一个
+	rate = 1000
+	if n := wake(start.Add(1115500 * time.Microsecond)); n != 1+95 {
+		t.Fatalf("速率提高后补发 %d 个请求, want 96", n)
+	}
+
+	// 速率降为0后只发送已排定的一个请求
+	rate = 0
+	if n := wake(start.Add(2 * time.Second)); n != 1 {
+		t.Fatalf("速率为0后发送了 %d 个请求, want 1", n)
+	}
+	if n := wake(start.Add(2500 * time.Millisecond)); n != 0 {
+		t.Fatalf("速率为0时发送了 %d 个请求", n)
+	}
+	// 速率恢复后从下一次检查速率的时刻开始按新速率发送
+	rate = 100
+	resume := p.next()
+	if n := wake(resume); n != 0 {
+		t.Fatalf("检查速率时发送了 %d 个请求", n)
+	}
+	if n := wake(resume.Add(1005 * time.Millisecond)); n != 100 {
+		t.Fatalf("速率恢复后1秒内发送 %d 个请求, want 100", n)
+	}
+}
@@ -0,0 +1,246 @@
+package ratecontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"splay/model"
+	"splay/pkg/config"
+)
+
+// 搜索探测失败的原因
+const (
+	limitP99       = "p99"        // 响应时间P99超过阈值
+	limitErrorRate = "error_rate" // 错误率超过阈值
+	limitPending   = "pending"    // 探测期间未完成的请求比例超过阈值，服务端处理速度跟不上发送速度
+	limitMaxQPS    = "max_qps"    // 达到配置的最大QPS仍未失败
+)
+
+// searchState 容量搜索的进度和结果
+type searchState struct {
+	mu        sync.Mutex
+	curve     []model.SearchPoint
+	best      int64  // 通过检查的最高QPS
+	limit     string // 第一个失败探测的原因
+	converged bool
+}
+
+// runSearch 搜索模式：按倍数提高QPS直到探测失败，再在最后通过和首次失败的QPS之间二分查找
+// 每次探测持续 step_seconds 秒，作为一个负载阶段单独统计；搜索完成后关闭 Done 通道
+func (rc *Controller) runSearch(ctx context.Context) {
+	defer close(rc.done)
+	defer rc.statsCollector.EndStages()
+
+	best, limit, finished := searchQPS(&rc.config.Search, func(qps int64) (bool, bool) {
+		return rc.probe(ctx, qps)
+	})
+	if finished {
+		rc.finishSearch(best, limit)
+	}
+}
+
+// searchQPS 执行容量搜索的QPS选择：probe 返回探测是否通过，done 为 true 时中止搜索
+// 返回已通过的最高QPS、覆盖首次失败原因的限制（达到最大QPS时为 limitMaxQPS）以及搜索是否完成
+func searchQPS(cfg *config.SearchConfig, probe func(qps int64) (ok, done bool)) (best int64, limit string, finished bool) {
+	var passed, failed int64
+
+	// 第一阶段：按倍数提高QPS，直到失败或达到最大QPS
+	for qps := int64(cfg.StartQPS); ; {
+		ok, done := probe(qps)
+		if done {
+			return passed, "", false
+		}
+		if !ok {
+			failed = qps
+			break
+		}
+		passed = qps
+		if qps >= int64(cfg.MaxQPS) {
+			return passed, limitMaxQPS, true
+		}
+		qps = min(max(int64(float64(qps)*cfg.GrowthFactor), qps+1), int64(cfg.MaxQPS))
+	}
+
+	// 第二阶段：二分查找，区间小于 precision 时结束
+	for float64(failed-passed) > max(float64(passed)*cfg.Precision, 1) {
+		mid := (passed + failed) / 2
+		ok, done := probe(mid)
+		if done {
+			return passed, "", false
+		}
+		if ok {
+			passed = mid
+		} else {
+			failed = mid
+		}
+	}
+	return passed, "", true
+}
+
+// probe 以指定QPS探测一个周期，返回是否满足阈值；压测时间到时 done 为 true
+// 探测结束后暂停发送并等待在途请求结束（最多 drain_seconds 秒），排空期间完成的请求计入本次探测，
+// 不会积压到下一次探测；未完成比例按探测结束时仍在途的请求计算
+func (rc *Controller) probe(ctx context.Context, qps int64) (bool, bool) {
+	cfg := &rc.config.Search
+	index := rc.search.probes()
+
+	rc.qps.Store(qps)
+	rc.statsCollector.BeginStage(index, "search", int(qps))
+	fmt.Printf("\n[搜索] 探测 %d: QPS %d\n", index+1, qps)
+
+	timer := time.NewTimer(cfg.GetStep())
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return false, true
+	case <-timer.C:
+	}
+
+	// 上一次探测已经排空，此时在途的请求都是本次探测发送的；排空超时的遗留请求也计入，结果偏保守
+	_, _, _, pending := rc.statsCollector.GetCurrentTotals()
+	rc.qps.Store(0)
+	if !rc.drain(ctx, cfg.GetDrain()) {
+		return false, true
+	}
+
+	rc.statsCollector.EndStages()
+	stages := rc.statsCollector.Stages()
+	stage := stages[len(stages)-1]
+
+	point := model.SearchPoint{
+		TargetQPS:    qps,
+		CompletedQPS: float32(float64(stage.TotalOps) / cfg.GetStep().Seconds()),
+		P99:          stage.ResponseTime.P99,
+		ErrorRate:    stage.ErrorRate,
+		Passed:       true,
+	}
+	if stage.TotalSent > 0 {
+		point.PendingRatio = float32(max(pending, 0)) / float32(stage.TotalSent)
+	}
+
+	var limit string
+	switch {
+	case float64(point.ErrorRate) > cfg.MaxErrorRate:
+		limit = limitErrorRate
+	case float64(point.P99) > cfg.MaxP99Ms:
+		limit = limitP99
+	case float64(point.PendingRatio) > cfg.MaxPendingRatio:
+		limit = limitPending
+	}
+	if limit != "" {
+		point.Passed = false
+		point.Limit = &limit
+	}
+	rc.search.record(point)
+
+	status := "通过"
+	if !point.Passed {
+		status = "失败(" + limit + ")"
+	}
+	fmt.Printf("[搜索] QPS %d: 完成QPS %.1f, 响应时间P99 %.1fms, 错误率 %.2f%%, 未完成 %.1f%% -> %s\n",
+		qps, point.CompletedQPS, point.P99, point.ErrorRate, point.PendingRatio*100, status)
+	return point.Passed, false
+}
+
+// drainPollInterval 排空在途请求时检查的间隔
+const drainPollInterval = 50 * time.Millisecond
+
+// drain 等待在途请求全部结束，最多等待 timeout；压测时间到时返回 false
+func (rc *Controller) drain(ctx context.Context, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, _, _, pending := rc.statsCollector.GetCurrentTotals(); pending <= 0 || !time.Now().Before(deadline) {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// probes 获取已完成的探测数
+func (s *searchState) probes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.curve)
+}
+
+// record 记录一次探测结果，第一个失败的探测决定触发的限制
+func (s *searchState) record(point model.SearchPoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.curve = append(s.curve, point)
+	if point.Passed {
+		s.best = max(s.best, point.TargetQPS)
+	} else if s.limit == "" {
+		s.limit = *point.Limit
+	}
+}
+
+// finishSearch 记录搜索结束，limit 非空时覆盖首次失败的原因（如达到最大QPS）
+func (rc *Controller) finishSearch(best int64, limit string) {
+	rc.search.mu.Lock()
+	defer rc.search.mu.Unlock()
+	rc.search.best = best
+	if limit != "" {
+		rc.search.limit = limit
+	}
+	rc.search.converged = true
+	fmt.Printf("\n[搜索] 完成: 最高可持续QPS %d\n", best)
+}
+
+// Done 返回搜索完成时关闭的通道，其他模式下不会关闭
+func (rc *Controller) Done() <-chan struct{} {
+	return rc.done
+}
+
+// SearchResult 获取容量搜索的结果，非搜索模式返回 nil
+// 压测时间到时搜索尚未收敛，结果中 converged 为 false，最高可持续QPS为已通过的最高QPS
+func (rc *Controller) SearchResult() *model.SearchResult {
+	if rc.config.Mode != "search" {
+		return nil
+	}
+
+	rc.search.mu.Lock()
+	defer rc.search.mu.Unlock()
+	result := &model.SearchResult{
+		MaxSustainableQPS: rc.search.best,
+		Converged:         rc.search.converged,
+		Curve:             append([]model.SearchPoint{}, rc.search.curve...),
+	}
+	if rc.search.limit != "" {
+		limit := rc.search.limit
+		result.Limit = &limit
+	}
+	return result
+}
+
+// PrintSearchResult 打印容量搜索的结果，非搜索模式不输出
+func (rc *Controller) PrintSearchResult() {
+	result := rc.SearchResult()
+	if result == nil {
+		return
+	}
+
+	fmt.Printf("\n=== 容量搜索 ===\n")
+	fmt.Printf("%-10s %-12s %-14s %-10s %-10s %s\n", "目标QPS", "完成QPS", "响应P99(ms)", "错误率%", "未完成%", "结果")
+	for _, p := range result.Curve {
+		status := "通过"
+		if !p.Passed {
+			status = "失败(" + *p.Limit + ")"
+		}
+		fmt.Printf("%-10d %-12.1f %-14.1f %-10.2f %-10.1f %s\n",
+			p.TargetQPS, p.CompletedQPS, p.P99, p.ErrorRate, p.PendingRatio*100, status)
+	}
+	fmt.Printf("最高可持续QPS: %d\n", result.MaxSustainableQPS)
+	if result.Limit != nil {
+		fmt.Printf("首先触发的限制: %s\n", *result.Limit)
+	}
+	if !result.Converged {
+		fmt.Printf("压测时间到，搜索未收敛，可调大 duration_seconds\n")
+	}
+}
@@ -0,0 +1,73 @@
+package ratecontroller
+
+import (
+	"slices"
+	"testing"
+
+	"splay/pkg/config"
+)
+
+func TestSearchQPS(t *testing.T) {
+	tests := []struct {
+		name         string
+		capacity     int64 // 不超过它的QPS探测通过
+		maxProbes    int   // 超过后探测返回 done，模拟压测时间到
+		wantBest     int64
+		wantLimit    string
+		wantFinished bool
+		wantProbes   []int64
+	}{
+		{
+			name:         "倍增后二分查找",
+			capacity:     1000,
+			maxProbes:    100,
+			wantBest:     1000,
+			wantFinished: true,
+			wantProbes:   []int64{100, 200, 400, 800, 1600, 1200, 1000, 1100, 1050},
+		},
+		{
+			name:         "达到最大QPS",
+			capacity:     1 << 40,
+			maxProbes:    100,
+			wantBest:     5000,
+			wantLimit:    limitMaxQPS,
+			wantFinished: true,
+			wantProbes:   []int64{100, 200, 400, 800, 1600, 3200, 5000},
+		},
+		{
+			name:         "初始QPS即失败",
+			capacity:     50,
+			maxProbes:    100,
+			wantBest:     50,
+			wantFinished: true,
+			wantProbes:   []int64{100, 50, 75, 62, 56, 53, 51},
+		},
+		{
+			name:       "时间到时未收敛",
+			capacity:   1000,
+			maxProbes:  3,
+			wantBest:   400,
+			wantProbes: []int64{100, 200, 400},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New().Search
+			cfg.MaxQPS = 5000
+			var probes []int64
+			best, limit, finished := searchQPS(&cfg, func(qps int64) (bool, bool) {
+				if len(probes) == tt.maxProbes {
+					return false, true
+				}
+				probes = append(probes, qps)
+				return qps <= tt.capacity, false
+			})
+			if best != tt.wantBest || limit != tt.wantLimit || finished != tt.wantFinished {
+				t.Errorf("searchQPS = %d %q %v, want %d %q %v", best, limit, finished, tt.wantBest, tt.wantLimit, tt.wantFinished)
+			}
+			if !slices.Equal(probes, tt.wantProbes) {
+				t.Errorf("探测序列 = %v, want %v", probes, tt.wantProbes)
+			}
+		})
+	}
+}
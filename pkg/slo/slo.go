@@ -0,0 +1,158 @@
+// Package slo 提供压测结果的 SLO 断言功能
+//
+// 需求和预设:
+// 1. 断言配置: 每项断言指定一个指标及允许的最小值和/或最大值，指标名称见 config.SLOMetrics
+// 2. 检查时机: 压测结束、最终报告生成（包括数据丢失对账和业务规则校验）之后
+// 3. 结果输出: 打印逐项的通过/失败表格，任一项失败时由调用方以非零状态退出，便于在流水线中拦截性能回退
+// 4. 指标缺失: 报告中没有对应数据（如未配置写入账本时的数据丢失率、没有成功请求时的延迟）的断言视为失败，避免误报通过
+//
+// 设计原则:
+// - 只读取最终报告，不依赖统计收集器的内部状态
+// - 延迟类指标取各业务操作中最差的值，任一操作超标即失败
+package slo
+
+import (
+	"fmt"
+
+	"splay/model"
+	"splay/pkg/config"
+)
+
+// Result 一项断言的检查结果
+type Result struct {
+	config.SLOConfig
+	Value     float64 // 报告中的指标值
+	Available bool    // 报告中是否有该指标的数据
+	Passed    bool
+}
+
+// Evaluate 对最终报告逐项检查 SLO 断言
+func Evaluate(report *model.StatsReport, slos []config.SLOConfig) []Result {
+	results := make([]Result, len(slos))
+	for i, slo := range slos {
+		value, available := metricValue(report, slo.Metric)
+		passed := available
+		if slo.Min != nil && value < *slo.Min {
+			passed = false
+		}
+		if slo.Max != nil && value > *slo.Max {
+			passed = false
+		}
+		results[i] = Result{SLOConfig: slo, Value: value, Available: available, Passed: passed}
+	}
+	return results
+}
+
+// AllPassed 判断是否所有断言都通过
+func AllPassed(results []Result) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Print 打印断言检查结果表格
+func Print(results []Result) {
+	fmt.Printf("\n=== SLO 断言 ===\n")
+	fmt.Printf("%-22s %-14s %-14s %s\n", "指标", "要求", "实际", "结果")
+	for _, r := range results {
+		actual := "无数据"
+		if r.Available {
+			actual = fmt.Sprintf("%.2f", r.Value)
+		}
+		status := "通过"
+		if !r.Passed {
+			status = "失败"
+		}
+		fmt.Printf("%-22s %-14s %-14s %s\n", r.Metric, requirement(r.SLOConfig), actual, status)
+	}
+}
+
+// requirement 将断言的阈值格式化为文本
+func requirement(slo config.SLOConfig) string {
+	switch {
+	case slo.Min != nil && slo.Max != nil:
+		return fmt.Sprintf("%g ~ %g", *slo.Min, *slo.Max)
+	case slo.Min != nil:
+		return fmt.Sprintf(">= %g", *slo.Min)
+	default:
+		return fmt.Sprintf("<= %g", *slo.Max)
+	}
+}
+
+// metricValue 从报告中读取指标值，报告中没有该指标的数据时返回 false
+func metricValue(report *model.StatsReport, metric string) (float64, bool) {
+	latency := report.LatencyAnalysis
+	switch metric {
+	case "avg_sent_qps":
+		return float64(report.PerformanceMetrics.AvgSentQPS), true
+	case "avg_completed_qps":
+		return float64(report.PerformanceMetrics.AvgCompletedQPS), true
+	case "error_rate":
+		return float64(report.PerformanceMetrics.ErrorRate), true
+	case "latency_p50_ms":
+		return worstPercentile(func(p *model.LatencyPercentiles) float32 { return p.P50 },
+			latency.SensorData, latency.SensorRW, latency.BatchRW, latency.Query)
+	case "latency_p99_ms":
+		return worstPercentile(func(p *model.LatencyPercentiles) float32 { return p.P99 },
+			latency.SensorData, latency.SensorRW, latency.BatchRW, latency.Query)
+	case "latency_p999_ms":
+		return worstPercentile(func(p *model.LatencyPercentiles) float32 { return p.P999 },
+			latency.SensorData, latency.SensorRW, latency.BatchRW, latency.Query)
+	case "response_time_p99_ms":
+		if latency.ResponseTime == nil {
+			return 0, false
+		}
+		rt := latency.ResponseTime
+		return worstPercentile(func(p *model.LatencyPercentiles) float32 { return p.P99 },
+			rt.SensorData, rt.SensorRW, rt.BatchRW, rt.Query)
+	case "persistence_p99_ms":
+		if latency.Persistence == nil || latency.Persistence.Percentiles == nil {
+			return 0, false
+		}
+		return float64(latency.Persistence.Percentiles.P99), true
+	case "save_delay_errors":
+		return float64(report.TotalSaveDelayErrors), true
+	case "data_loss_rate":
+		if report.DataLossRate == nil {
+			return 0, false
+		}
+		return float64(*report.DataLossRate), true
+	case "lost_writes":
+		if report.TotalLostWrites == nil {
+			return 0, false
+		}
+		return float64(*report.TotalLostWrites), true
+	case "business_rule_errors":
+		if report.TotalBusinessRuleErrors == nil {
+			return 0, false
+		}
+		return float64(*report.TotalBusinessRuleErrors), true
+	}
+	return 0, false
+}
+
+// worstPercentile 取有成功请求的各操作中最大的百分位数，所有操作都没有成功请求时返回 false
+func worstPercentile(pick func(*model.LatencyPercentiles) float32, dists ...model.LatencyDistribution) (float64, bool) {
+	var worst float32
+	available := false
+	for _, dist := range dists {
+		if dist.Percentiles != nil && hasSamples(dist) {
+			worst = max(worst, pick(dist.Percentiles))
+			available = true
+		}
+	}
+	return float64(worst), available
+}
+
+// hasSamples 判断延迟分布中是否有成功请求
+func hasSamples(dist model.LatencyDistribution) bool {
+	for _, count := range dist.Buckets {
+		if count > 0 {
+			return true
+		}
+	}
+	return false
+}
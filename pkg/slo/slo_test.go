@@ -0,0 +1,117 @@
+package slo
+
+import (
+	"testing"
+
+	"splay/model"
+	"splay/pkg/config"
+)
+
+// testReport 构造一个 sensor-rw 有请求（P99 为 p99 毫秒）、其他操作没有请求的报告
+func testReport(p99 float32, errorRate float32) *model.StatsReport {
+	empty := model.LatencyDistribution{
+		Buckets:     make([]int64, 13),
+		Percentiles: &model.LatencyPercentiles{},
+	}
+	rw := model.LatencyDistribution{
+		Buckets:     []int64{0, 0, 0, 0, 0, 80, 20, 0, 0, 0, 0, 0, 0},
+		Percentiles: &model.LatencyPercentiles{P50: p99 / 2, P90: p99 * 0.9, P95: p99 * 0.95, P99: p99, P999: p99 * 1.1},
+	}
+	return &model.StatsReport{
+		PerformanceMetrics: model.PerformanceMetrics{AvgSentQPS: 1000, AvgCompletedQPS: 990, ErrorRate: errorRate},
+		LatencyAnalysis: model.LatencyAnalysis{
+			SensorData: empty,
+			SensorRW:   rw,
+			BatchRW:    empty,
+			Query:      empty,
+		},
+	}
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+func TestEvaluate(t *testing.T) {
+	noSamples := testReport(0, 100)
+	noSamples.LatencyAnalysis.SensorRW.Buckets = make([]int64, 13)
+
+	tests := []struct {
+		name          string
+		report        *model.StatsReport
+		slo           config.SLOConfig
+		wantValue     float64
+		wantAvailable bool
+		wantPassed    bool
+	}{
+		{
+			name:          "P99 低于上限",
+			report:        testReport(120, 0),
+			slo:           config.SLOConfig{Metric: "latency_p99_ms", Max: bound(500)},
+			wantValue:     120,
+			wantAvailable: true,
+			wantPassed:    true,
+		},
+		{
+			name:          "P99 超过上限",
+			report:        testReport(800, 0),
+			slo:           config.SLOConfig{Metric: "latency_p99_ms", Max: bound(500)},
+			wantValue:     800,
+			wantAvailable: true,
+			wantPassed:    false,
+		},
+		{
+			name:          "没有成功请求时延迟视为无数据",
+			report:        noSamples,
+			slo:           config.SLOConfig{Metric: "latency_p99_ms", Max: bound(500)},
+			wantAvailable: false,
+			wantPassed:    false,
+		},
+		{
+			name:          "QPS 低于下限",
+			report:        testReport(120, 0),
+			slo:           config.SLOConfig{Metric: "avg_completed_qps", Min: bound(10000)},
+			wantValue:     990,
+			wantAvailable: true,
+			wantPassed:    false,
+		},
+		{
+			name:          "同时指定上下限",
+			report:        testReport(120, 0.5),
+			slo:           config.SLOConfig{Metric: "error_rate", Min: bound(0), Max: bound(1)},
+			wantValue:     0.5,
+			wantAvailable: true,
+			wantPassed:    true,
+		},
+		{
+			name:          "未配置写入账本时数据丢失率无数据",
+			report:        testReport(120, 0),
+			slo:           config.SLOConfig{Metric: "data_loss_rate", Max: bound(0.5)},
+			wantAvailable: false,
+			wantPassed:    false,
+		},
+		{
+			name:          "未知指标",
+			report:        testReport(120, 0),
+			slo:           config.SLOConfig{Metric: "unknown", Max: bound(1)},
+			wantAvailable: false,
+			wantPassed:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Evaluate(tt.report, []config.SLOConfig{tt.slo})
+			if len(results) != 1 {
+				t.Fatalf("Evaluate 返回 %d 个结果, want 1", len(results))
+			}
+			r := results[0]
+			if r.Available != tt.wantAvailable || r.Passed != tt.wantPassed {
+				t.Errorf("Available=%v Passed=%v, want Available=%v Passed=%v",
+					r.Available, r.Passed, tt.wantAvailable, tt.wantPassed)
+			}
+			if tt.wantAvailable && r.Value != tt.wantValue {
+				t.Errorf("Value = %v, want %v", r.Value, tt.wantValue)
+			}
+		})
+	}
+}
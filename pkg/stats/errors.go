@@ -0,0 +1,177 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"splay/model"
+)
+
+// ErrorKind 失败请求的错误类型
+type ErrorKind int
+
+const (
+	ErrorOther             ErrorKind = iota // 无法归类的错误
+	ErrorTimeout                            // 请求超时
+	ErrorConnectionRefused                  // 连接被拒绝，服务端未监听或已崩溃
+	ErrorConnectionReset                    // 连接被重置或提前关闭
+	ErrorSocketExhausted                    // 客户端本地端口或文件描述符耗尽
+	ErrorHTTP4xx                            // 服务端返回 4xx
+	ErrorHTTP503                            // 服务端返回 503，通常表示过载
+	ErrorHTTP5xx                            // 服务端返回 503 以外的 5xx
+	ErrorDecode                             // 响应解析失败
+	numErrorKinds
+)
+
+// errorKindNames 错误类型在输出中的名称
+var errorKindNames = [numErrorKinds]string{
+	ErrorOther:             "其他",
+	ErrorTimeout:           "超时",
+	ErrorConnectionRefused: "连接拒绝",
+	ErrorConnectionReset:   "连接重置",
+	ErrorSocketExhausted:   "客户端端口耗尽",
+	ErrorHTTP4xx:           "4xx",
+	ErrorHTTP503:           "503",
+	ErrorHTTP5xx:           "5xx",
+	ErrorDecode:            "解析失败",
+}
+
+func (k ErrorKind) String() string {
+	return errorKindNames[k]
+}
+
+// ClassifyError 根据请求错误和HTTP状态码判断错误类型，statusCode 为 0 表示未收到响应
+func ClassifyError(err error, statusCode int) ErrorKind {
+	if err == nil {
+		switch {
+		case statusCode == 503:
+			return ErrorHTTP503
+		case statusCode >= 500:
+			return ErrorHTTP5xx
+		case statusCode >= 400:
+			return ErrorHTTP4xx
+		}
+		return ErrorOther
+	}
+
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorConnectionReset
+	case errors.Is(err, syscall.EADDRNOTAVAIL), errors.Is(err, syscall.EMFILE), errors.Is(err, syscall.ENFILE):
+		return ErrorSocketExhausted
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorDecode
+	}
+	return ErrorOther
+}
+
+// errorBreakdown 按错误类型和HTTP状态码统计的失败请求数
+type errorBreakdown struct {
+	kinds [numErrorKinds]int64
+
+	mu          sync.Mutex
+	statusCodes map[int]int64
+}
+
+func (b *errorBreakdown) record(kind ErrorKind, statusCode int) {
+	atomic.AddInt64(&b.kinds[kind], 1)
+	if statusCode == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.statusCodes == nil {
+		b.statusCodes = make(map[int]int64)
+	}
+	b.statusCodes[statusCode]++
+}
+
+// snapshot 获取各错误类型的计数
+func (b *errorBreakdown) snapshot() [numErrorKinds]int64 {
+	var kinds [numErrorKinds]int64
+	for i := range kinds {
+		kinds[i] = atomic.LoadInt64(&b.kinds[i])
+	}
+	return kinds
+}
+
+// formatErrorKinds 将错误分类格式化为单行文本，只包含非零的类型，没有错误时返回空字符串
+func formatErrorKinds(kinds [numErrorKinds]int64) string {
+	var parts []string
+	for kind, count := range kinds {
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", ErrorKind(kind), count))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// print 打印错误分类明细
+func (b *errorBreakdown) print() {
+	kinds := b.snapshot()
+	summary := formatErrorKinds(kinds)
+	if summary == "" {
+		return
+	}
+
+	fmt.Printf("错误分类: %s\n", summary)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	codes := make([]int, 0, len(b.statusCodes))
+	for code := range b.statusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Printf("  HTTP %d: %d\n", code, b.statusCodes[code])
+	}
+}
+
+// report 生成错误分类报告
+func (b *errorBreakdown) report() model.ErrorBreakdown {
+	kinds := b.snapshot()
+	r := model.ErrorBreakdown{
+		Other:             kinds[ErrorOther],
+		Timeout:           kinds[ErrorTimeout],
+		ConnectionRefused: kinds[ErrorConnectionRefused],
+		ConnectionReset:   kinds[ErrorConnectionReset],
+		SocketExhausted:   kinds[ErrorSocketExhausted],
+		Http4xx:           kinds[ErrorHTTP4xx],
+		Http503:           kinds[ErrorHTTP503],
+		Http5xx:           kinds[ErrorHTTP5xx],
+		Decode:            kinds[ErrorDecode],
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.statusCodes) > 0 {
+		statusCodes := make(map[string]int64, len(b.statusCodes))
+		for code, count := range b.statusCodes {
+			statusCodes[strconv.Itoa(code)] = count
+		}
+		r.StatusCodes = &statusCodes
+	}
+	return r
+}
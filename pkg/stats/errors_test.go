@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		want       ErrorKind
+	}{
+		{"503", nil, 503, ErrorHTTP503},
+		{"500", nil, 500, ErrorHTTP5xx},
+		{"404", nil, 404, ErrorHTTP4xx},
+		{"非错误状态码", nil, 302, ErrorOther},
+		{"请求超时", &url.Error{Op: "Post", URL: "http://x", Err: context.DeadlineExceeded}, 0, ErrorTimeout},
+		{"读写超时", fmt.Errorf("read: %w", os.ErrDeadlineExceeded), 0, ErrorTimeout},
+		{"连接拒绝", &url.Error{Op: "Post", URL: "http://x",
+			Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, 0, ErrorConnectionRefused},
+		{"连接重置", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, 0, ErrorConnectionReset},
+		{"连接提前关闭", &url.Error{Op: "Post", URL: "http://x", Err: io.EOF}, 0, ErrorConnectionReset},
+		{"本地端口耗尽", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.EADDRNOTAVAIL)}, 0, ErrorSocketExhausted},
+		{"文件描述符耗尽", fmt.Errorf("dial: %w", syscall.EMFILE), 0, ErrorSocketExhausted},
+		{"响应解析失败", fmt.Errorf("解析响应失败: %w", syntaxErr), 200, ErrorDecode},
+		{"未知错误", fmt.Errorf("unknown"), 0, ErrorOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err, tt.statusCode); got != tt.want {
+				t.Errorf("ClassifyError(%v, %d) = %s, want %s", tt.err, tt.statusCode, got, tt.want)
+			}
+		})
+	}
+}
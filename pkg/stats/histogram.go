@@ -0,0 +1,147 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+
+	"splay/model"
+)
+
+// Histogram 无锁的对数线性直方图（HDR风格），用于计算延迟百分位数
+//
+// 以微秒记录延迟，小于 2^histogramSubBits 微秒的值按1微秒精确计数，
+// 更大的值按2的幂分段，每段再线性划分为 histogramHalfCount 个子桶，
+// 因此任意值的相对误差不超过 1/histogramHalfCount（约0.8%）
+type Histogram struct {
+	counts     []int64
+	totalCount int64
+}
+
+const (
+	histogramSubBits   = 8                     // 线性区间的位数
+	histogramSubCount  = 1 << histogramSubBits // 线性区间的桶数（0-255微秒）
+	histogramHalfCount = histogramSubCount / 2 // 每个2的幂分段内的子桶数
+	histogramMaxBits   = 36                    // 可记录的最大值约 2^36 微秒（约19小时），更大的值计入最后一个桶
+	histogramBuckets   = histogramSubCount + (histogramMaxBits-histogramSubBits)*histogramHalfCount
+)
+
+func NewHistogram() *Histogram {
+	return &Histogram{counts: make([]int64, histogramBuckets)}
+}
+
+// histogramIndex 计算微秒值所在的桶
+func histogramIndex(us int64) int {
+	if us < histogramSubCount {
+		return int(max(us, 0))
+	}
+	exp := bits.Len64(uint64(us)) - histogramSubBits
+	index := histogramSubCount + (exp-1)*histogramHalfCount + int(us>>exp) - histogramHalfCount
+	return min(index, histogramBuckets-1)
+}
+
+// histogramValue 获取桶代表的微秒值（桶区间的中点）
+func histogramValue(index int) float64 {
+	if index < histogramSubCount {
+		return float64(index)
+	}
+	exp := (index-histogramSubCount)/histogramHalfCount + 1
+	mantissa := (index-histogramSubCount)%histogramHalfCount + histogramHalfCount
+	lower := float64(int64(mantissa) << exp)
+	return lower + float64(int64(1)<<exp)/2
+}
+
+// Record 记录一个延迟值
+func (h *Histogram) Record(latency time.Duration) {
+	atomic.AddInt64(&h.counts[histogramIndex(latency.Microseconds())], 1)
+	atomic.AddInt64(&h.totalCount, 1)
+}
+
+// Percentiles 计算多个百分位数（毫秒），qs 为 0-1 之间的分位点，须按升序排列
+func (h *Histogram) Percentiles(qs ...float64) []float64 {
+	counts := make([]int64, len(h.counts))
+	for i := range counts {
+		counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	return countsPercentiles(counts, atomic.LoadInt64(&h.totalCount), qs...)
+}
+
+// countsPercentiles 根据各桶计数计算百分位数（毫秒）
+func countsPercentiles(counts []int64, total int64, qs ...float64) []float64 {
+	result := make([]float64, len(qs))
+	if total == 0 {
+		return result
+	}
+
+	var cumulative int64
+	next := 0
+	for i := range counts {
+		cumulative += counts[i]
+		for next < len(qs) && cumulative >= int64(math.Ceil(qs[next]*float64(total))) {
+			result[next] = histogramValue(i) / 1000
+			next++
+		}
+		if next == len(qs) {
+			break
+		}
+	}
+	// 并发记录导致计数之和小于 total 时，剩余的分位点取最后一个非空桶
+	for ; next < len(qs); next++ {
+		result[next] = result[max(next-1, 0)]
+	}
+	return result
+}
+
+// histogramCounts 获取多个直方图各桶计数之和，用于计算一段时间内记录的值的百分位数
+func histogramCounts(histograms ...*Histogram) []int64 {
+	counts := make([]int64, histogramBuckets)
+	for _, h := range histograms {
+		for i := range counts {
+			counts[i] += atomic.LoadInt64(&h.counts[i])
+		}
+	}
+	return counts
+}
+
+// intervalPercentiles 计算两次计数快照之间记录的值的百分位数，last 为 nil 时从零开始计算
+func intervalPercentiles(current, last []int64) Percentiles {
+	interval := make([]int64, len(current))
+	var total int64
+	for i := range current {
+		interval[i] = current[i]
+		if last != nil {
+			interval[i] -= last[i]
+		}
+		total += interval[i]
+	}
+
+	p := countsPercentiles(interval, total, 0.5, 0.9, 0.95, 0.99, 0.999)
+	return Percentiles{P50: p[0], P90: p[1], P95: p[2], P99: p[3], P999: p[4]}
+}
+
+// Percentiles 常用的延迟百分位数（毫秒）
+type Percentiles struct {
+	P50  float64
+	P90  float64
+	P95  float64
+	P99  float64
+	P999 float64
+}
+
+// GetPercentiles 计算 P50/P90/P95/P99/P99.9
+func (h *Histogram) GetPercentiles() Percentiles {
+	p := h.Percentiles(0.5, 0.9, 0.95, 0.99, 0.999)
+	return Percentiles{P50: p[0], P90: p[1], P95: p[2], P99: p[3], P999: p[4]}
+}
+
+// ToModel 转换为上报格式
+func (p Percentiles) ToModel() *model.LatencyPercentiles {
+	return &model.LatencyPercentiles{
+		P50:  float32(p.P50),
+		P90:  float32(p.P90),
+		P95:  float32(p.P95),
+		P99:  float32(p.P99),
+		P999: float32(p.P999),
+	}
+}
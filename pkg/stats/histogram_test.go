@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		us   int64
+		want int
+	}{
+		{"负数计入第一个桶", -5, 0},
+		{"零", 0, 0},
+		{"线性区间按1微秒计数", 1, 1},
+		{"线性区间上限", histogramSubCount - 1, histogramSubCount - 1},
+		{"第一个分段的起点", histogramSubCount, histogramSubCount},
+		{"第一个分段按2微秒划分", histogramSubCount + 1, histogramSubCount},
+		{"第一个分段的第二个子桶", histogramSubCount + 2, histogramSubCount + 1},
+		{"第二个分段的起点", 2 * histogramSubCount, histogramSubCount + histogramHalfCount},
+		{"超过上限计入最后一个桶", math.MaxInt64, histogramBuckets - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := histogramIndex(tt.us); got != tt.want {
+				t.Errorf("histogramIndex(%d) = %d, want %d", tt.us, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramIndexRelativeError(t *testing.T) {
+	last := 0
+	for us := int64(1); us < 1<<histogramMaxBits; us = us*9/8 + 1 {
+		index := histogramIndex(us)
+		if index < last {
+			t.Fatalf("histogramIndex(%d) = %d, 小于前一个值的桶 %d", us, index, last)
+		}
+		last = index
+
+		value := histogramValue(index)
+		if relative := math.Abs(value-float64(us)) / float64(us); relative > 1.0/histogramHalfCount {
+			t.Errorf("histogramValue(histogramIndex(%d)) = %.1f, 相对误差 %.4f 超过 %.4f",
+				us, value, relative, 1.0/histogramHalfCount)
+		}
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []time.Duration
+		want   Percentiles
+	}{
+		{
+			name: "没有记录",
+			want: Percentiles{},
+		},
+		{
+			name:   "单个值",
+			values: []time.Duration{5 * time.Millisecond},
+			want:   Percentiles{P50: 5, P90: 5, P95: 5, P99: 5, P999: 5},
+		},
+		{
+			name:   "1-1000ms均匀分布",
+			values: durations(1000, func(i int) time.Duration { return time.Duration(i+1) * time.Millisecond }),
+			want:   Percentiles{P50: 500, P90: 900, P95: 950, P99: 990, P999: 999},
+		},
+		{
+			name: "长尾",
+			values: durations(1000, func(i int) time.Duration {
+				if i < 990 {
+					return time.Millisecond
+				}
+				return time.Second
+			}),
+			want: Percentiles{P50: 1, P90: 1, P95: 1, P99: 1, P999: 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistogram()
+			for _, v := range tt.values {
+				h.Record(v)
+			}
+			got := h.GetPercentiles()
+			assertPercentiles(t, got, tt.want)
+		})
+	}
+}
+
+func TestIntervalPercentiles(t *testing.T) {
+	h := NewHistogram()
+	for range 100 {
+		h.Record(time.Second)
+	}
+	last := histogramCounts(h)
+	for range 100 {
+		h.Record(10 * time.Millisecond)
+	}
+
+	// 快照之后只记录了 10ms，之前的 1s 不应出现在区间百分位数中
+	assertPercentiles(t, intervalPercentiles(histogramCounts(h), last),
+		Percentiles{P50: 10, P90: 10, P95: 10, P99: 10, P999: 10})
+	assertPercentiles(t, intervalPercentiles(histogramCounts(h), nil),
+		Percentiles{P50: 10, P90: 1000, P95: 1000, P99: 1000, P999: 1000})
+}
+
+func durations(n int, f func(i int) time.Duration) []time.Duration {
+	values := make([]time.Duration, n)
+	for i := range values {
+		values[i] = f(i)
+	}
+	return values
+}
+
+// assertPercentiles 检查百分位数，允许直方图的相对误差
+func assertPercentiles(t *testing.T, got, want Percentiles) {
+	t.Helper()
+	pairs := []struct {
+		name      string
+		got, want float64
+	}{
+		{"P50", got.P50, want.P50},
+		{"P90", got.P90, want.P90},
+		{"P95", got.P95, want.P95},
+		{"P99", got.P99, want.P99},
+		{"P99.9", got.P999, want.P999},
+	}
+	for _, p := range pairs {
+		if math.Abs(p.got-p.want) > p.want/histogramHalfCount {
+			t.Errorf("%s = %.3f, want %.3f", p.name, p.got, p.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"splay/model"
+)
+
+// recoveryStreak 判定恢复所需的连续成功请求数，恢复之后零星的失败不会重新打开中断窗口
+const recoveryStreak = 10
+
+// outageTracker 记录服务端重启后客户端视角的中断窗口
+// 重启触发后第一个失败的请求标记中断开始；在已知失败请求之后发送、且连续 recoveryStreak 个完成的请求都成功时判定恢复，
+// 其中最早发送的成功请求标记恢复时刻，恢复请求发送之前发送的失败请求计为中断期间的错误。
+// 结果按完成顺序到达，恢复按发送时刻判定：重启前发送、重启后才完成的成功请求不会过早结束中断窗口，
+// 恢复之后发送的零星失败请求不计入中断窗口，也不会推迟恢复时刻
+type outageTracker struct {
+	mu            sync.Mutex
+	triggeredAt   time.Time // 触发重启的时刻，为零值时不跟踪
+	firstError    time.Time // 触发后第一个失败请求的完成时刻
+	lastErrorSent time.Time // 中断期间完成的失败请求中最晚的发送时刻
+	errors        int64     // 中断期间的失败请求数
+	streak        int       // 在 lastErrorSent 之后发送的连续成功请求数
+	candidateSent time.Time // 连续成功请求中最早的发送时刻
+	candidateAt   time.Time // 该成功请求的完成时刻
+	recoverySent  time.Time // 判定恢复时的 candidateSent，为零值表示尚未恢复
+	recoveredAt   time.Time // 判定恢复时的 candidateAt
+}
+
+// MarkRestart 记录触发服务端重启的时刻，开始跟踪中断窗口
+func (sc *Collector) MarkRestart(t time.Time) {
+	sc.outage.mu.Lock()
+	defer sc.outage.mu.Unlock()
+	sc.outage.triggeredAt = t
+}
+
+// observe 记录一个业务请求的结果，sentAt 为请求实际发送的时刻
+func (o *outageTracker) observe(sentAt, completedAt time.Time, success bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.triggeredAt.IsZero() || completedAt.Before(o.triggeredAt) {
+		return
+	}
+
+	if !o.recoverySent.IsZero() {
+		// 已恢复：恢复请求之前发送、较晚完成的失败仍属于中断期间，之后发送的失败是零星错误，不重新打开中断窗口
+		if !success && sentAt.Before(o.recoverySent) {
+			o.errors++
+		}
+		return
+	}
+
+	if !success {
+		if o.firstError.IsZero() {
+			o.firstError = completedAt
+		}
+		o.errors++
+		if sentAt.After(o.lastErrorSent) {
+			o.lastErrorSent = sentAt
+		}
+		// 连续成功请求之后发送的请求仍然失败，说明服务当时并未恢复
+		if o.streak > 0 && !sentAt.Before(o.candidateSent) {
+			o.streak = 0
+		}
+		return
+	}
+
+	if o.firstError.IsZero() || !sentAt.After(o.lastErrorSent) {
+		return
+	}
+	if o.streak == 0 || sentAt.Before(o.candidateSent) {
+		o.candidateSent = sentAt
+		o.candidateAt = completedAt
+	}
+	o.streak++
+	if o.streak >= recoveryStreak {
+		o.recoverySent = o.candidateSent
+		o.recoveredAt = o.candidateAt
+	}
+}
+
+// report 生成中断窗口报告，未触发重启时返回 nil
+func (o *outageTracker) report() *model.RestartStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.triggeredAt.IsZero() {
+		return nil
+	}
+
+	r := &model.RestartStats{
+		TriggeredAt:        o.triggeredAt,
+		Recovered:          !o.recoveredAt.IsZero(),
+		ErrorsDuringOutage: o.errors,
+	}
+	if !o.firstError.IsZero() {
+		firstErrorMs := msSince(o.triggeredAt, o.firstError)
+		r.FirstErrorMs = &firstErrorMs
+	}
+	if !o.recoveredAt.IsZero() {
+		recoveryTimeMs := msSince(o.triggeredAt, o.recoveredAt)
+		outageWindowMs := msSince(o.firstError, o.recoveredAt)
+		r.RecoveryTimeMs = &recoveryTimeMs
+		r.OutageWindowMs = &outageWindowMs
+	}
+	return r
+}
+
+func msSince(from, to time.Time) float32 {
+	return float32(to.Sub(from).Seconds() * 1000)
+}
+
+// print 打印中断窗口统计
+func (o *outageTracker) print() {
+	r := o.report()
+	if r == nil {
+		return
+	}
+
+	fmt.Printf("\n=== 崩溃恢复 ===\n")
+	fmt.Printf("触发重启: %s\n", r.TriggeredAt.Format("15:04:05.000"))
+	if r.FirstErrorMs == nil {
+		fmt.Printf("重启后未观察到失败请求\n")
+		return
+	}
+	fmt.Printf("首个失败请求: 触发后 %.0fms\n", *r.FirstErrorMs)
+	if r.Recovered {
+		fmt.Printf("首个恢复成功请求: 触发后 %.0fms\n", *r.RecoveryTimeMs)
+		fmt.Printf("中断窗口: %.0fms\n", *r.OutageWindowMs)
+	} else {
+		fmt.Printf("压测结束前服务未恢复\n")
+	}
+	fmt.Printf("中断期间错误数: %d\n", r.ErrorsDuringOutage)
+}
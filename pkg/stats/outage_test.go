@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+// outageEvent 一个请求的结果，时刻为相对触发重启的毫秒数
+type outageEvent struct {
+	sentMs, completedMs int
+	success             bool
+}
+
+// successes 生成从 fromMs 开始每 stepMs 发送一个、latencyMs 后完成的成功请求
+func successes(fromMs, n, stepMs, latencyMs int) []outageEvent {
+	events := make([]outageEvent, n)
+	for i := range events {
+		sent := fromMs + i*stepMs
+		events[i] = outageEvent{sent, sent + latencyMs, true}
+	}
+	return events
+}
+
+func failures(fromMs, n, stepMs, latencyMs int) []outageEvent {
+	events := successes(fromMs, n, stepMs, latencyMs)
+	for i := range events {
+		events[i].success = false
+	}
+	return events
+}
+
+func concat(groups ...[]outageEvent) []outageEvent {
+	var events []outageEvent
+	for _, g := range groups {
+		events = append(events, g...)
+	}
+	return events
+}
+
+func TestOutageTracker(t *testing.T) {
+	tests := []struct {
+		name          string
+		events        []outageEvent // 按完成顺序
+		wantRecovered bool
+		wantRecovery  float32 // 恢复请求的完成时刻（ms）
+		wantWindow    float32
+		wantErrors    int64
+	}{
+		{
+			name: "干净的中断",
+			events: concat(
+				successes(-50, 5, 10, 60),
+				failures(100, 20, 10, 5),
+				successes(300, 30, 10, 5),
+			),
+			wantRecovered: true,
+			wantRecovery:  305,
+			wantWindow:    200,
+			wantErrors:    20,
+		},
+		{
+			name: "恢复过程中反复失败",
+			events: concat(
+				failures(100, 10, 10, 5),
+				successes(200, 3, 10, 5),
+				failures(230, 2, 10, 5),
+				successes(250, 5, 10, 5),
+				failures(300, 1, 10, 5),
+				successes(310, 20, 10, 5),
+			),
+			wantRecovered: true,
+			wantRecovery:  315,
+			wantWindow:    210,
+			wantErrors:    13,
+		},
+		{
+			name: "恢复后零星的失败",
+			events: concat(
+				failures(100, 10, 10, 5),
+				successes(200, 50, 10, 5),
+				failures(900, 1, 10, 5),
+				successes(910, 10, 10, 5),
+				failures(5000, 1, 10, 5),
+			),
+			wantRecovered: true,
+			wantRecovery:  205,
+			wantWindow:    100,
+			wantErrors:    10,
+		},
+		{
+			name: "较晚完成的中断期间失败仍计入",
+			events: concat(
+				failures(100, 5, 10, 5),
+				successes(200, 10, 10, 5),
+				[]outageEvent{{150, 1150, false}},
+				successes(300, 5, 10, 5),
+			),
+			wantRecovered: true,
+			wantRecovery:  205,
+			wantWindow:    100,
+			wantErrors:    6,
+		},
+		{
+			name: "连续成功不足时未恢复",
+			events: concat(
+				failures(100, 5, 10, 5),
+				successes(200, recoveryStreak-1, 10, 5),
+			),
+			wantRecovered: false,
+			wantErrors:    5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := time.Unix(1000, 0)
+			at := func(ms int) time.Time { return trigger.Add(time.Duration(ms) * time.Millisecond) }
+
+			o := outageTracker{triggeredAt: trigger}
+			for _, e := range tt.events {
+				o.observe(at(e.sentMs), at(e.completedMs), e.success)
+			}
+
+			r := o.report()
+			if r.Recovered != tt.wantRecovered || r.ErrorsDuringOutage != tt.wantErrors {
+				t.Fatalf("Recovered=%v ErrorsDuringOutage=%d, want Recovered=%v ErrorsDuringOutage=%d",
+					r.Recovered, r.ErrorsDuringOutage, tt.wantRecovered, tt.wantErrors)
+			}
+			if !tt.wantRecovered {
+				return
+			}
+			if *r.RecoveryTimeMs != tt.wantRecovery || *r.OutageWindowMs != tt.wantWindow {
+				t.Errorf("RecoveryTimeMs=%.0f OutageWindowMs=%.0f, want %.0f %.0f",
+					*r.RecoveryTimeMs, *r.OutageWindowMs, tt.wantRecovery, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestOutageTrackerNotTriggered(t *testing.T) {
+	var o outageTracker
+	o.observe(time.Now(), time.Now(), false)
+	if r := o.report(); r != nil {
+		t.Errorf("report = %+v, want nil", r)
+	}
+}
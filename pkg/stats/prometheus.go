@@ -0,0 +1,171 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"splay/model"
+)
+
+// prometheusOperations 导出指标的操作类型，verify-query 为持久化探测的查询
+var prometheusOperations = []string{"sensor-data", "sensor-rw", "batch-rw", "query", "verify-query"}
+
+// errorKindLabels 错误类型在指标标签中的名称，与报告中 ErrorBreakdown 的字段一致
+var errorKindLabels = [numErrorKinds]string{
+	ErrorOther:             "other",
+	ErrorTimeout:           "timeout",
+	ErrorConnectionRefused: "connection_refused",
+	ErrorConnectionReset:   "connection_reset",
+	ErrorSocketExhausted:   "socket_exhausted",
+	ErrorHTTP4xx:           "http_4xx",
+	ErrorHTTP503:           "http_503",
+	ErrorHTTP5xx:           "http_5xx",
+	ErrorDecode:            "decode",
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出当前统计的 HTTP 处理器
+func (sc *Collector) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		sc.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus 以 Prometheus 文本格式（0.0.4）输出当前统计
+// 各计数器独立读取，同一次输出中的指标之间可能相差正在处理的少量请求
+func (sc *Collector) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeHeader(bw, "splay_requests_sent_total", "counter", "已发送的请求数")
+	for _, op := range prometheusOperations {
+		sent, _, _, _ := sc.operationFields(op)
+		writeSample(bw, "splay_requests_sent_total", opLabel(op), atomic.LoadInt64(sent))
+	}
+	writeHeader(bw, "splay_requests_completed_total", "counter", "成功完成的请求数")
+	for _, op := range prometheusOperations {
+		_, ops, _, _ := sc.operationFields(op)
+		writeSample(bw, "splay_requests_completed_total", opLabel(op), atomic.LoadInt64(ops))
+	}
+	writeHeader(bw, "splay_requests_errors_total", "counter", "失败的请求数")
+	for _, op := range prometheusOperations {
+		_, _, errors, _ := sc.operationFields(op)
+		writeSample(bw, "splay_requests_errors_total", opLabel(op), atomic.LoadInt64(errors))
+	}
+
+	writeHeader(bw, "splay_request_errors_by_kind_total", "counter", "失败的业务请求按错误类型的分类计数")
+	kinds := sc.errorKinds.snapshot()
+	for kind, count := range kinds {
+		writeSample(bw, "splay_request_errors_by_kind_total", `kind="`+errorKindLabels[kind]+`"`, count)
+	}
+
+	writeHeader(bw, "splay_validation_errors_total", "counter", "响应校验失败的请求数")
+	for _, op := range prometheusOperations {
+		if field := sc.validationErrorsField(op); field != nil {
+			writeSample(bw, "splay_validation_errors_total", opLabel(op), atomic.LoadInt64(field))
+		}
+	}
+	writeHeader(bw, "splay_payload_bytes_sent_total", "counter", "已发送的负载数据字节数")
+	for _, op := range prometheusOperations {
+		if field := sc.sentBytesField(op); field != nil {
+			writeSample(bw, "splay_payload_bytes_sent_total", opLabel(op), atomic.LoadInt64(field))
+		}
+	}
+	writeHeader(bw, "splay_batch_rows_total", "counter", "批量操作成功写入的数据条数")
+	writeSample(bw, "splay_batch_rows_total", "", atomic.LoadInt64(&sc.batchRWRows))
+
+	_, _, _, pending := sc.GetCurrentTotals()
+	writeHeader(bw, "splay_requests_pending", "gauge", "已发送但尚未完成的业务请求数")
+	writeSample(bw, "splay_requests_pending", "", pending)
+
+	writeHeader(bw, "splay_request_duration_seconds", "histogram", "请求的服务时间")
+	for _, op := range prometheusOperations {
+		_, _, _, latencyStats := sc.operationFields(op)
+		writeHistogram(bw, "splay_request_duration_seconds", opLabel(op), latencyStats)
+	}
+	writeHeader(bw, "splay_response_time_seconds", "histogram", "从计划发送时刻计算的响应时间，包含客户端排队延迟")
+	for _, op := range prometheusOperations {
+		if responseStats := sc.responseStatsField(op); responseStats != nil {
+			writeHistogram(bw, "splay_response_time_seconds", opLabel(op), responseStats)
+		}
+	}
+
+	writeHeader(bw, "splay_persistence_visible_seconds", "histogram", "写入确认到数据在MySQL中可见的时间")
+	writeHistogram(bw, "splay_persistence_visible_seconds", "", sc.persistenceStats)
+	writeHeader(bw, "splay_persistence_not_visible_total", "counter", "探测超时仍不可见的写入数")
+	writeSample(bw, "splay_persistence_not_visible_total", "", atomic.LoadInt64(&sc.persistenceNotVisible))
+	writeHeader(bw, "splay_persistence_sla_missed_total", "counter", "超过持久化时限才可见或始终不可见的写入数")
+	writeSample(bw, "splay_persistence_sla_missed_total", "", atomic.LoadInt64(&sc.saveDelayErrors))
+
+	if sc.verifierReport != nil {
+		writeVerifierMetrics(bw, sc.verifierReport())
+	}
+
+	return bw.Flush()
+}
+
+// SetVerifierReport 设置校验子系统运行统计的来源，应在提供指标接口之前调用
+func (sc *Collector) SetVerifierReport(report func() *model.VerifierStats) {
+	sc.verifierReport = report
+}
+
+// writeVerifierMetrics 输出校验子系统的队列、抽样和连接池统计，用于判断持久化探测是否成为瓶颈
+func writeVerifierMetrics(w *bufio.Writer, r *model.VerifierStats) {
+	writeHeader(w, "splay_verifier_queue_depth", "gauge", "排队等待执行的持久化探测数")
+	writeSample(w, "splay_verifier_queue_depth", "", r.QueueDepth)
+	writeHeader(w, "splay_verifier_sampled_total", "counter", "按采样率抽中进行持久化探测的写入数")
+	writeSample(w, "splay_verifier_sampled_total", "", r.Sampled)
+	writeHeader(w, "splay_verifier_dropped_total", "counter", "被丢弃的持久化探测数，包括队列已满和排队超时")
+	writeSample(w, "splay_verifier_dropped_total", "", r.Dropped)
+	writeHeader(w, "splay_verifier_expired_total", "counter", "排队超过最长探测时间而丢弃的持久化探测数")
+	writeSample(w, "splay_verifier_expired_total", "", r.Expired)
+	writeHeader(w, "splay_verifier_pool_wait_total", "counter", "等待MySQL连接池空闲连接的次数")
+	writeSample(w, "splay_verifier_pool_wait_total", "", r.PoolWaitCount)
+	writeHeader(w, "splay_verifier_pool_wait_seconds_total", "counter", "等待MySQL连接池空闲连接的累计时间")
+	fmt.Fprintf(w, "splay_verifier_pool_wait_seconds_total %s\n",
+		strconv.FormatFloat(float64(r.PoolWaitDurationMs)/1000, 'g', -1, 64))
+}
+
+func opLabel(operation string) string {
+	return `operation="` + operation + `"`
+}
+
+func writeHeader(w *bufio.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value int64) {
+	if labels != "" {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, labels, value)
+	} else {
+		fmt.Fprintf(w, "%s %d\n", name, value)
+	}
+}
+
+// writeHistogram 将延迟桶输出为累计的 Prometheus 直方图（秒）
+// _count 取各桶计数之和，保证与 +Inf 桶一致
+func writeHistogram(w *bufio.Writer, name, labels string, ls *LatencyStats) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+
+	var cumulative int64
+	for i, bucket := range latencyBuckets {
+		cumulative += atomic.LoadInt64(&ls.buckets[i])
+		le := strconv.FormatFloat(bucket/1000, 'g', -1, 64)
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, sep, le, cumulative)
+	}
+	cumulative += atomic.LoadInt64(&ls.buckets[len(latencyBuckets)])
+	fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, cumulative)
+
+	sum := strconv.FormatFloat(float64(atomic.LoadInt64(&ls.totalTime))/1e9, 'g', -1, 64)
+	if labels != "" {
+		fmt.Fprintf(w, "%s_sum{%s} %s\n%s_count{%s} %d\n", name, labels, sum, name, labels, cumulative)
+	} else {
+		fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, sum, name, cumulative)
+	}
+}
@@ -0,0 +1,37 @@
+package stats
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"splay/model"
+)
+
+func TestWritePrometheusVerifier(t *testing.T) {
+	sc := NewCollector(context.Background())
+
+	var out strings.Builder
+	sc.WritePrometheus(&out)
+	if strings.Contains(out.String(), "splay_verifier_") {
+		t.Errorf("未设置校验子系统时输出了校验指标")
+	}
+
+	sc.SetVerifierReport(func() *model.VerifierStats {
+		return &model.VerifierStats{QueueDepth: 7, Sampled: 100, Dropped: 3, Expired: 1, PoolWaitCount: 5, PoolWaitDurationMs: 250}
+	})
+	out.Reset()
+	sc.WritePrometheus(&out)
+	for _, want := range []string{
+		"splay_verifier_queue_depth 7\n",
+		"splay_verifier_sampled_total 100\n",
+		"splay_verifier_dropped_total 3\n",
+		"splay_verifier_expired_total 1\n",
+		"splay_verifier_pool_wait_total 5\n",
+		"splay_verifier_pool_wait_seconds_total 0.25\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("输出中缺少 %q", want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"splay/model"
+)
+
+// stageMark 阶段边界时刻的累计统计快照
+type stageMark struct {
+	time           time.Time
+	sent           int64
+	ops            int64
+	errors         int64
+	latencyCounts  []int64 // 各业务操作服务时间直方图的计数之和
+	responseCounts []int64 // 各业务操作响应时间直方图的计数之和
+}
+
+// stageStart 正在进行的负载阶段
+type stageStart struct {
+	stageMark
+	index  int
+	ramp   string
+	target int
+}
+
+// stageTracker 按负载阶段切分统计
+// 阶段的统计为阶段时间窗口内完成的业务请求，在上一阶段发送、本阶段完成的请求计入本阶段
+type stageTracker struct {
+	mu       sync.Mutex
+	current  *stageStart
+	finished []model.StageStats
+}
+
+// latencyCounts 获取各业务操作服务时间直方图的计数之和
+func (sc *Collector) latencyCounts() []int64 {
+	return histogramCounts(sc.sensorDataStats.histogram, sc.sensorRWStats.histogram,
+		sc.batchRWStats.histogram, sc.queryStats.histogram)
+}
+
+// markStage 记录当前的累计统计快照
+func (sc *Collector) markStage() stageMark {
+	sent, ops, errors, _ := sc.GetCurrentTotals()
+	return stageMark{
+		time:          time.Now(),
+		sent:          sent,
+		ops:           ops,
+		errors:        errors,
+		latencyCounts: sc.latencyCounts(),
+		responseCounts: histogramCounts(sc.sensorDataResponseStats.histogram, sc.sensorRWResponseStats.histogram,
+			sc.batchRWResponseStats.histogram, sc.queryResponseStats.histogram),
+	}
+}
+
+// BeginStage 结束当前负载阶段并开始新的阶段，index 从0开始，target 为阶段的目标QPS或并发数
+func (sc *Collector) BeginStage(index int, ramp string, target int) {
+	mark := sc.markStage()
+
+	sc.stages.mu.Lock()
+	defer sc.stages.mu.Unlock()
+	if current := sc.stages.current; current != nil {
+		sc.stages.finished = append(sc.stages.finished, sc.stageStats(current, mark))
+	}
+	sc.stages.current = &stageStart{stageMark: mark, index: index, ramp: ramp, target: target}
+}
+
+// EndStages 结束当前负载阶段，压测结束时调用
+func (sc *Collector) EndStages() {
+	mark := sc.markStage()
+
+	sc.stages.mu.Lock()
+	defer sc.stages.mu.Unlock()
+	if current := sc.stages.current; current != nil {
+		sc.stages.finished = append(sc.stages.finished, sc.stageStats(current, mark))
+		sc.stages.current = nil
+	}
+}
+
+// stageStats 根据阶段开始和结束时的快照计算阶段统计
+func (sc *Collector) stageStats(start *stageStart, end stageMark) model.StageStats {
+	elapsed := end.time.Sub(start.time).Seconds()
+	ops := end.ops - start.ops
+	errors := end.errors - start.errors
+
+	stats := model.StageStats{
+		Index:        start.index,
+		Ramp:         start.ramp,
+		Target:       int64(start.target),
+		StartElapsed: float32(start.time.Sub(sc.startTime).Seconds()),
+		Elapsed:      float32(elapsed),
+		TotalSent:    end.sent - start.sent,
+		TotalOps:     ops,
+		TotalErrors:  errors,
+		Latency:      *intervalPercentiles(end.latencyCounts, start.latencyCounts).ToModel(),
+		ResponseTime: *intervalPercentiles(end.responseCounts, start.responseCounts).ToModel(),
+	}
+	if elapsed > 0 {
+		stats.AvgSentQPS = float32(float64(stats.TotalSent) / elapsed)
+		stats.AvgCompletedQPS = float32(float64(ops) / elapsed)
+	}
+	if ops+errors > 0 {
+		stats.ErrorRate = float32(errors) * 100 / float32(ops+errors)
+	}
+	return stats
+}
+
+// Stages 获取各负载阶段的统计，未配置负载阶段时返回 nil
+// 尚未结束的阶段统计到当前时刻
+func (sc *Collector) Stages() []model.StageStats {
+	mark := sc.markStage()
+
+	sc.stages.mu.Lock()
+	defer sc.stages.mu.Unlock()
+	stages := append([]model.StageStats(nil), sc.stages.finished...)
+	if current := sc.stages.current; current != nil {
+		stages = append(stages, sc.stageStats(current, mark))
+	}
+	return stages
+}
+
+// printStages 打印各负载阶段的统计
+func (sc *Collector) printStages() {
+	stages := sc.Stages()
+	if len(stages) == 0 {
+		return
+	}
+
+	fmt.Printf("\n=== 负载阶段 ===\n")
+	for _, s := range stages {
+		fmt.Printf("阶段 %d (%s, 目标 %d): %.1fs, 发送QPS %.1f, 完成QPS %.1f, 错误率 %.2f%%\n",
+			s.Index+1, s.Ramp, s.Target, s.Elapsed, s.AvgSentQPS, s.AvgCompletedQPS, s.ErrorRate)
+		fmt.Printf("  延迟P50/P99/P99.9(ms): %.1f/%.1f/%.1f | 响应时间P50/P99/P99.9(ms): %.1f/%.1f/%.1f\n",
+			s.Latency.P50, s.Latency.P99, s.Latency.P999,
+			s.ResponseTime.P50, s.ResponseTime.P99, s.ResponseTime.P999)
+	}
+}
@@ -2,13 +2,17 @@
 //
 // 需求和预设:
 // 1. 实时统计收集: Worker通过Push模式主动推送操作结果，避免阻塞
-// 2. 延迟分布分析: 提供详细的延迟桶统计，支持P50、P99等百分位数分析
+// 2. 延迟分布分析: 提供延迟桶统计，并用对数线性直方图计算相对误差有界的P50/P90/P95/P99/P99.9
 // 3. QPS计算: 实时计算瞬时QPS和平均QPS，便于性能监控
 // 4. 多操作类型支持: 分别统计传感器数据上报、读写操作、批量操作、查询操作
-// 5. 错误率统计: 记录各类操作的成功率和错误率
+// 5. 错误率统计: 记录各类操作的成功率和错误率，并按超时、连接拒绝/重置、客户端端口耗尽、HTTP状态码、响应解析失败分类
 // 6. 非阻塞设计: 统计收集不影响Worker的执行性能
 // 7. 并发安全: 支持多个Worker并发推送统计数据
 // 8. 最终报告: 提供详细的测试总结报告
+// 9. 时间线: 保存每个统计周期的速率、错误、待处理数和周期内延迟百分位数，便于定位拐点、GC停顿和重启
+// 10. 负载阶段: 按阶段切分统计，分别报告各阶段的吞吐量、错误率和延迟百分位数
+// 11. 指标导出: 以 Prometheus 文本格式输出实时计数和延迟直方图，不依赖第三方库
+// 12. 统计窗口: 提供连续的统计窗口，供闭环速率控制读取最近一个周期的延迟百分位数和在途请求数
 //
 // 设计原则:
 // - 使用缓冲channel避免Worker阻塞
@@ -29,11 +33,12 @@ import (
 
 // LatencyStats 延迟统计结构
 type LatencyStats struct {
-	buckets    []int64 // 每个桶的计数
-	totalCount int64   // 总请求数
-	totalTime  int64   // 总延迟时间（纳秒）
-	maxLatency int64   // 最大延迟（纳秒）
-	minLatency int64   // 最小延迟（纳秒）
+	buckets    []int64    // 每个桶的计数
+	totalCount int64      // 总请求数
+	totalTime  int64      // 总延迟时间（纳秒）
+	maxLatency int64      // 最大延迟（纳秒）
+	minLatency int64      // 最小延迟（纳秒）
+	histogram  *Histogram // 用于计算百分位数的对数线性直方图
 
 	// 高优先级请求统计 (Priority >= 3)
 	highPriorityBuckets    []int64 // 高优先级请求的延迟桶
@@ -41,17 +46,28 @@ type LatencyStats struct {
 	highPriorityTotalTime  int64   // 高优先级请求总延迟时间（纳秒）
 	highPriorityMaxLatency int64   // 高优先级请求最大延迟（纳秒）
 	highPriorityMinLatency int64   // 高优先级请求最小延迟（纳秒）
+	highPriorityHistogram  *Histogram
 }
 
 // 延迟桶定义（毫秒）
 var latencyBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000}
 
+// BucketLabel 获取延迟桶的名称，i 与 model.LatencyDistribution.Buckets 的下标对应
+func BucketLabel(i int) string {
+	if i < len(latencyBuckets) {
+		return fmt.Sprintf("≤%.0fms", latencyBuckets[i])
+	}
+	return fmt.Sprintf(">%.0fms", latencyBuckets[len(latencyBuckets)-1])
+}
+
 func NewLatencyStats() *LatencyStats {
 	return &LatencyStats{
 		buckets:                make([]int64, len(latencyBuckets)+1), // +1 for >5000ms
 		minLatency:             int64(^uint64(0) >> 1),               // 初始化为最大值
 		highPriorityBuckets:    make([]int64, len(latencyBuckets)+1), // +1 for >5000ms
 		highPriorityMinLatency: int64(^uint64(0) >> 1),               // 初始化为最大值
+		histogram:              NewHistogram(),
+		highPriorityHistogram:  NewHistogram(),
 	}
 }
 
@@ -92,6 +108,7 @@ func (ls *LatencyStats) Record(latency time.Duration, priority int) {
 	}
 
 	atomic.AddInt64(&ls.buckets[bucketIndex], 1)
+	ls.histogram.Record(latency)
 
 	// 如果是高优先级请求 (Priority >= 3)，同时记录到高优先级统计中
 	if priority >= 3 {
@@ -121,6 +138,7 @@ func (ls *LatencyStats) Record(latency time.Duration, priority int) {
 
 		// 记录到高优先级桶中
 		atomic.AddInt64(&ls.highPriorityBuckets[bucketIndex], 1)
+		ls.highPriorityHistogram.Record(latency)
 	}
 }
 
@@ -169,6 +187,22 @@ func (ls *LatencyStats) GetHighPriorityStats() (float64, float64, float64, []int
 	return avgLatency, maxLatencyMs, minLatencyMs, buckets, totalCount
 }
 
+// GetPercentiles 获取延迟百分位数（毫秒）
+func (ls *LatencyStats) GetPercentiles() Percentiles {
+	return ls.histogram.GetPercentiles()
+}
+
+// GetHighPriorityPercentiles 获取高优先级请求的延迟百分位数（毫秒）
+func (ls *LatencyStats) GetHighPriorityPercentiles() Percentiles {
+	return ls.highPriorityHistogram.GetPercentiles()
+}
+
+// printPercentiles 打印百分位数
+func printPercentiles(indent string, p Percentiles) {
+	fmt.Printf("%sP50=%.2fms, P90=%.2fms, P95=%.2fms, P99=%.2fms, P99.9=%.2fms\n",
+		indent, p.P50, p.P90, p.P95, p.P99, p.P999)
+}
+
 func (ls *LatencyStats) PrintDistribution() {
 	avgLatency, maxLatency, minLatency, buckets := ls.GetStats()
 	totalCount := atomic.LoadInt64(&ls.totalCount)
@@ -179,6 +213,7 @@ func (ls *LatencyStats) PrintDistribution() {
 	}
 
 	fmt.Printf("  平均=%.2fms, 最小=%.2fms, 最大=%.2fms\n", avgLatency, minLatency, maxLatency)
+	printPercentiles("  ", ls.GetPercentiles())
 	fmt.Printf("  延迟分布:\n")
 
 	for i, bucket := range latencyBuckets {
@@ -197,6 +232,7 @@ func (ls *LatencyStats) PrintDistribution() {
 	if highTotalCount > 0 {
 		fmt.Printf("\n  高优先级请求 (Priority≥3): %d 个请求\n", highTotalCount)
 		fmt.Printf("  高优先级平均=%.2fms, 最小=%.2fms, 最大=%.2fms\n", highAvgLatency, highMinLatency, highMaxLatency)
+		printPercentiles("  高优先级", ls.GetHighPriorityPercentiles())
 		fmt.Printf("  高优先级延迟分布:\n")
 
 		for i, bucket := range latencyBuckets {
@@ -218,45 +254,128 @@ type Result struct {
 	Latency   time.Duration
 	Priority  int
 	Success   bool
-	IsSent    bool // true表示请求开始发送，false表示请求完成
+	IsSent    bool      // true表示请求开始发送，false表示请求完成
+	Completed time.Time // 请求完成的时刻，用于统计服务端重启后的中断窗口
+
+	ResponseTime time.Duration // 从计划发送时刻到完成的响应时间，包含客户端排队延迟
+	Rows         int           // 批量操作携带的数据条数
+	Bytes        int           // 发送事件携带的负载数据字节数
+
+	IsValidationError bool // true表示请求成功但响应内容校验失败
+	PageOverlaps      int  // 查询结果中与之前的页重复的记录数，大于0时只计数
+
+	ErrorKind  ErrorKind // 失败请求的错误类型
+	StatusCode int       // 失败请求的HTTP状态码，未收到响应时为0
+
+	IsPersistence bool // true表示持久化探测结果，Latency 为写入确认到数据可见的时间
+	SLAMissed     bool // 持久化探测中数据超过时限才可见或始终不可见
 }
 
 // Collector 统计收集器
 type Collector struct {
 	// 各操作的延迟统计
 	sensorDataStats *LatencyStats
+	sensorRWStats   *LatencyStats
+	batchRWStats    *LatencyStats
+	queryStats      *LatencyStats
 	verifyStats     *LatencyStats
 
+	// 各操作的响应时间统计：从计划发送时刻计算，QPS模式下包含客户端排队延迟，避免协调遗漏
+	sensorDataResponseStats *LatencyStats
+	sensorRWResponseStats   *LatencyStats
+	batchRWResponseStats    *LatencyStats
+	queryResponseStats      *LatencyStats
+
+	// 持久化探测统计：数据从写入确认到可见的时间分布
+	persistenceStats      *LatencyStats
+	persistenceNotVisible int64 // 探测超时仍不可见的写入数
+	saveDelayErrors       int64 // 超过持久化时限才可见或始终不可见的写入数
+
 	// 操作计数
 	sensorDataSent   int64
+	sensorRWSent     int64
+	batchRWSent      int64
+	querySent        int64
 	verifySent       int64
 	sensorDataOps    int64
+	sensorRWOps      int64
+	batchRWOps       int64
+	queryOps         int64
 	verifyOps        int64
 	sensorDataErrors int64
+	sensorRWErrors   int64
+	batchRWErrors    int64
+	queryErrors      int64
 	verifyErrors     int64
 
+	// 已发送的负载数据字节数
+	sensorDataBytes int64
+	sensorRWBytes   int64
+	batchRWBytes    int64
+	lastTotalBytes  int64
+
+	// 批量操作成功写入的数据条数
+	batchRWRows     int64
+	lastBatchRWRows int64
+
+	// 响应校验失败计数
+	sensorRWValidationErrors int64
+	batchRWValidationErrors  int64
+	queryValidationErrors    int64
+	queryPageOverlaps        int64 // 查询翻页时与之前的页重复的记录数
+
+	// 服务端重启后的中断窗口
+	outage outageTracker
+
+	// 业务操作失败请求的错误分类
+	errorKinds     errorBreakdown
+	lastErrorKinds [numErrorKinds]int64
+
+	// 各统计周期的快照
+	timeline timeline
+
+	// 各负载阶段的统计
+	stages stageTracker
+
 	// 时间统计
 	startTime     time.Time
 	lastPrintTime time.Time
 
 	// 上次统计的操作数（用于计算瞬时 QPS）
-	lastSensorDataSent int64
-	lastVerifySent     int64
-	lastSensorDataOps  int64
-	lastVerifyOps      int64
+	lastTotalSent  int64
+	lastVerifySent int64
+	lastTotalOps   int64
+	lastVerifyOps  int64
+
+	// 上次统计的错误数（用于计算周期内的错误数）
+	lastTotalErrors  int64
+	lastVerifyErrors int64
 
 	// 用于推送统计结果的通道
 	resultChan chan Result
+
+	// 校验子系统的运行统计，设置后随 Prometheus 指标一起输出
+	verifierReport func() *model.VerifierStats
 }
 
 func NewCollector(ctx context.Context) *Collector {
 	now := time.Now()
 	sc := &Collector{
-		sensorDataStats: NewLatencyStats(),
-		verifyStats:     NewLatencyStats(),
-		startTime:       now,
-		lastPrintTime:   now,
-		resultChan:      make(chan Result, 1000000), // 缓冲通道
+		sensorDataStats:  NewLatencyStats(),
+		sensorRWStats:    NewLatencyStats(),
+		batchRWStats:     NewLatencyStats(),
+		queryStats:       NewLatencyStats(),
+		verifyStats:      NewLatencyStats(),
+		persistenceStats: NewLatencyStats(),
+
+		sensorDataResponseStats: NewLatencyStats(),
+		sensorRWResponseStats:   NewLatencyStats(),
+		batchRWResponseStats:    NewLatencyStats(),
+		queryResponseStats:      NewLatencyStats(),
+
+		startTime:     now,
+		lastPrintTime: now,
+		resultChan:    make(chan Result, 1000000), // 缓冲通道
 	}
 
 	// 启动统计处理协程
@@ -269,11 +388,13 @@ func NewCollector(ctx context.Context) *Collector {
 func (sc *Collector) PushResult(operation string, latency time.Duration, priority int, success bool) {
 	select {
 	case sc.resultChan <- Result{
-		Operation: operation,
-		Latency:   latency,
-		Priority:  priority,
-		Success:   success,
-		IsSent:    false, // 兼容性方法，默认为完成事件
+		Operation:    operation,
+		Latency:      latency,
+		Priority:     priority,
+		Success:      success,
+		IsSent:       false,
+		Completed:    time.Now(),
+		ResponseTime: latency, // 兼容性方法，默认为完成事件
 	}:
 	default:
 		// 如果通道满了，丢弃该统计结果
@@ -281,8 +402,8 @@ func (sc *Collector) PushResult(operation string, latency time.Duration, priorit
 	}
 }
 
-// PushSentEvent 推送请求发送事件（立即记录发送统计）
-func (sc *Collector) PushSentEvent(operation string) {
+// PushSentEvent 推送请求发送事件（立即记录发送统计），payloadBytes 为请求携带的负载数据字节数
+func (sc *Collector) PushSentEvent(operation string, payloadBytes int) {
 	select {
 	case sc.resultChan <- Result{
 		Operation: operation,
@@ -290,6 +411,7 @@ func (sc *Collector) PushSentEvent(operation string) {
 		Priority:  0,
 		Success:   true,
 		IsSent:    true,
+		Bytes:     payloadBytes,
 	}:
 	default:
 		// 如果通道满了，丢弃该统计结果
@@ -297,15 +419,98 @@ func (sc *Collector) PushSentEvent(operation string) {
 	}
 }
 
-// PushCompletedResult 推送请求完成结果
-func (sc *Collector) PushCompletedResult(operation string, latency time.Duration, priority int, success bool) {
+// PushCompletedResult 推送请求完成结果，latency 为服务时间，responseTime 为从计划发送时刻计算的响应时间
+func (sc *Collector) PushCompletedResult(operation string, latency, responseTime time.Duration, priority int, success bool) {
 	select {
 	case sc.resultChan <- Result{
-		Operation: operation,
-		Latency:   latency,
-		Priority:  priority,
-		Success:   success,
-		IsSent:    false,
+		Operation:    operation,
+		Latency:      latency,
+		Priority:     priority,
+		Success:      success,
+		IsSent:       false,
+		Completed:    time.Now(),
+		ResponseTime: responseTime,
+	}:
+	default:
+		// 如果通道满了，丢弃该统计结果
+		// 这样可以避免阻塞 Worker
+	}
+}
+
+// PushFailedResult 推送失败请求结果，根据请求错误和HTTP状态码（未收到响应时为0）分类
+func (sc *Collector) PushFailedResult(operation string, latency time.Duration, priority int, err error, statusCode int) {
+	select {
+	case sc.resultChan <- Result{
+		Operation:  operation,
+		Latency:    latency,
+		Priority:   priority,
+		Success:    false,
+		IsSent:     false,
+		Completed:  time.Now(),
+		ErrorKind:  ClassifyError(err, statusCode),
+		StatusCode: statusCode,
+	}:
+	default:
+		// 如果通道满了，丢弃该统计结果
+		// 这样可以避免阻塞 Worker
+	}
+}
+
+// PushCompletedBatchResult 推送批量请求完成结果，rows 为该批次的数据条数
+func (sc *Collector) PushCompletedBatchResult(operation string, latency, responseTime time.Duration, rows int, success bool) {
+	select {
+	case sc.resultChan <- Result{
+		Operation:    operation,
+		Latency:      latency,
+		Success:      success,
+		IsSent:       false,
+		Completed:    time.Now(),
+		ResponseTime: responseTime,
+		Rows:         rows,
+	}:
+	default:
+		// 如果通道满了，丢弃该统计结果
+		// 这样可以避免阻塞 Worker
+	}
+}
+
+// PushValidationError 推送响应校验失败事件（请求本身已按成功记录）
+func (sc *Collector) PushValidationError(operation string) {
+	select {
+	case sc.resultChan <- Result{
+		Operation:         operation,
+		IsValidationError: true,
+	}:
+	default:
+		// 如果通道满了，丢弃该统计结果
+		// 这样可以避免阻塞 Worker
+	}
+}
+
+// PushPageOverlap 推送查询翻页重叠事件，overlaps 为与之前的页重复的记录数
+func (sc *Collector) PushPageOverlap(operation string, overlaps int) {
+	select {
+	case sc.resultChan <- Result{
+		Operation:    operation,
+		PageOverlaps: overlaps,
+	}:
+	default:
+		// 如果通道满了，丢弃该统计结果
+		// 这样可以避免阻塞 Worker
+	}
+}
+
+// PushPersistenceResult 推送持久化探测结果，visibleAfter 为写入确认到数据可见的时间，
+// visible 表示探测期间数据是否可见，slaMissed 表示是否超过持久化时限
+func (sc *Collector) PushPersistenceResult(visibleAfter time.Duration, priority int, visible, slaMissed bool) {
+	select {
+	case sc.resultChan <- Result{
+		Operation:     "persistence",
+		Latency:       visibleAfter,
+		Priority:      priority,
+		Success:       visible,
+		IsPersistence: true,
+		SLAMissed:     slaMissed,
 	}:
 	default:
 		// 如果通道满了，丢弃该统计结果
@@ -334,44 +539,150 @@ func (sc *Collector) processResults(ctx context.Context) {
 }
 
 func (sc *Collector) processResult(result Result) {
+	if result.IsValidationError {
+		if validationErrors := sc.validationErrorsField(result.Operation); validationErrors != nil {
+			atomic.AddInt64(validationErrors, 1)
+		}
+		return
+	}
+	if result.PageOverlaps > 0 {
+		atomic.AddInt64(&sc.queryPageOverlaps, int64(result.PageOverlaps))
+		return
+	}
+
+	if result.IsPersistence {
+		if result.Success {
+			sc.persistenceStats.Record(result.Latency, result.Priority)
+		} else {
+			atomic.AddInt64(&sc.persistenceNotVisible, 1)
+		}
+		if result.SLAMissed {
+			atomic.AddInt64(&sc.saveDelayErrors, 1)
+		}
+		return
+	}
+
+	sent, ops, errors, latencyStats := sc.operationFields(result.Operation)
+	if latencyStats == nil {
+		return
+	}
+
 	if result.IsSent {
-		// 处理发送事件，只记录发送计数
-		switch result.Operation {
-		case "sensor-data":
-			atomic.AddInt64(&sc.sensorDataSent, 1)
-		case "verify-query":
-			atomic.AddInt64(&sc.verifySent, 1)
+		// 处理发送事件，只记录发送计数和负载字节数
+		atomic.AddInt64(sent, 1)
+		if sentBytes := sc.sentBytesField(result.Operation); sentBytes != nil {
+			atomic.AddInt64(sentBytes, int64(result.Bytes))
 		}
 	} else {
 		// 处理完成事件，记录完成计数、错误和延迟统计
-		switch result.Operation {
-		case "sensor-data":
-			if result.Success {
-				atomic.AddInt64(&sc.sensorDataOps, 1)
-				sc.sensorDataStats.Record(result.Latency, result.Priority)
-			} else {
-				atomic.AddInt64(&sc.sensorDataErrors, 1)
+		if result.Operation != "verify-query" {
+			sc.outage.observe(result.Completed.Add(-result.Latency), result.Completed, result.Success)
+		}
+		if result.Success {
+			atomic.AddInt64(ops, 1)
+			latencyStats.Record(result.Latency, result.Priority)
+			if responseStats := sc.responseStatsField(result.Operation); responseStats != nil {
+				responseStats.Record(result.ResponseTime, result.Priority)
+			}
+			if result.Operation == "batch-rw" {
+				atomic.AddInt64(&sc.batchRWRows, int64(result.Rows))
 			}
-		case "verify-query":
-			if result.Success {
-				atomic.AddInt64(&sc.verifyOps, 1)
-				sc.verifyStats.Record(result.Latency, result.Priority)
-			} else {
-				atomic.AddInt64(&sc.verifyErrors, 1)
+		} else {
+			atomic.AddInt64(errors, 1)
+			if result.Operation != "verify-query" {
+				sc.errorKinds.record(result.ErrorKind, result.StatusCode)
 			}
 		}
 	}
 }
 
+// operationFields 返回操作类型对应的发送、完成、错误计数和延迟统计
+func (sc *Collector) operationFields(operation string) (*int64, *int64, *int64, *LatencyStats) {
+	switch operation {
+	case "sensor-data":
+		return &sc.sensorDataSent, &sc.sensorDataOps, &sc.sensorDataErrors, sc.sensorDataStats
+	case "sensor-rw":
+		return &sc.sensorRWSent, &sc.sensorRWOps, &sc.sensorRWErrors, sc.sensorRWStats
+	case "batch-rw":
+		return &sc.batchRWSent, &sc.batchRWOps, &sc.batchRWErrors, sc.batchRWStats
+	case "query":
+		return &sc.querySent, &sc.queryOps, &sc.queryErrors, sc.queryStats
+	case "verify-query":
+		return &sc.verifySent, &sc.verifyOps, &sc.verifyErrors, sc.verifyStats
+	}
+	return nil, nil, nil, nil
+}
+
+// responseStatsField 返回操作类型对应的响应时间统计
+func (sc *Collector) responseStatsField(operation string) *LatencyStats {
+	switch operation {
+	case "sensor-data":
+		return sc.sensorDataResponseStats
+	case "sensor-rw":
+		return sc.sensorRWResponseStats
+	case "batch-rw":
+		return sc.batchRWResponseStats
+	case "query":
+		return sc.queryResponseStats
+	}
+	return nil
+}
+
+// sentBytesField 返回操作类型对应的已发送负载字节数
+func (sc *Collector) sentBytesField(operation string) *int64 {
+	switch operation {
+	case "sensor-data":
+		return &sc.sensorDataBytes
+	case "sensor-rw":
+		return &sc.sensorRWBytes
+	case "batch-rw":
+		return &sc.batchRWBytes
+	}
+	return nil
+}
+
+// getTotalBytesSent 获取已发送的负载数据总字节数
+func (sc *Collector) getTotalBytesSent() int64 {
+	return atomic.LoadInt64(&sc.sensorDataBytes) + atomic.LoadInt64(&sc.sensorRWBytes) + atomic.LoadInt64(&sc.batchRWBytes)
+}
+
+// validationErrorsField 返回操作类型对应的响应校验失败计数
+func (sc *Collector) validationErrorsField(operation string) *int64 {
+	switch operation {
+	case "sensor-rw":
+		return &sc.sensorRWValidationErrors
+	case "batch-rw":
+		return &sc.batchRWValidationErrors
+	case "query":
+		return &sc.queryValidationErrors
+	}
+	return nil
+}
+
+// Elapsed 获取距压测开始的时间
+func (sc *Collector) Elapsed() time.Duration {
+	return time.Since(sc.startTime)
+}
+
+// GetCurrentTotals 获取业务操作（不含验证操作）的发送、完成、错误和待处理总数
 func (sc *Collector) GetCurrentTotals() (int64, int64, int64, int64) {
-	totalSent := atomic.LoadInt64(&sc.sensorDataSent)
-	totalOps := atomic.LoadInt64(&sc.sensorDataOps)
-	totalErrors := atomic.LoadInt64(&sc.sensorDataErrors)
+	totalSent := atomic.LoadInt64(&sc.sensorDataSent) + atomic.LoadInt64(&sc.sensorRWSent) +
+		atomic.LoadInt64(&sc.batchRWSent) + atomic.LoadInt64(&sc.querySent)
+	totalOps := atomic.LoadInt64(&sc.sensorDataOps) + atomic.LoadInt64(&sc.sensorRWOps) +
+		atomic.LoadInt64(&sc.batchRWOps) + atomic.LoadInt64(&sc.queryOps)
+	totalErrors := atomic.LoadInt64(&sc.sensorDataErrors) + atomic.LoadInt64(&sc.sensorRWErrors) +
+		atomic.LoadInt64(&sc.batchRWErrors) + atomic.LoadInt64(&sc.queryErrors)
 	pending := totalSent - totalOps - totalErrors
 
 	return totalSent, totalOps, totalErrors, pending
 }
 
+// formatTailLatency 将 P50/P90/P95/P99/P99.9 格式化为紧凑的文本
+func formatTailLatency(ls *LatencyStats) string {
+	p := ls.GetPercentiles()
+	return fmt.Sprintf("%.1f/%.1f/%.1f/%.1f/%.1f", p.P50, p.P90, p.P95, p.P99, p.P999)
+}
+
 func (sc *Collector) PrintRealtime() {
 	now := time.Now()
 	elapsed := now.Sub(sc.lastPrintTime).Seconds()
@@ -380,18 +691,24 @@ func (sc *Collector) PrintRealtime() {
 	totalSent, totalOps, totalErrors, pending := sc.GetCurrentTotals()
 
 	// 计算瞬时发送速率
-	currentSensorDataSent := atomic.LoadInt64(&sc.sensorDataSent)
 	currentVerifySent := atomic.LoadInt64(&sc.verifySent)
 
-	instantSendQPS := float64(currentSensorDataSent+currentVerifySent-
-		sc.lastSensorDataSent-sc.lastVerifySent) / elapsed
+	instantSendQPS := float64(totalSent+currentVerifySent-
+		sc.lastTotalSent-sc.lastVerifySent) / elapsed
 
 	// 计算瞬时完成速率
-	currentSensorDataOps := atomic.LoadInt64(&sc.sensorDataOps)
 	currentVerifyOps := atomic.LoadInt64(&sc.verifyOps)
 
-	instantDoneQPS := float64(currentSensorDataOps+currentVerifyOps-
-		sc.lastSensorDataOps-sc.lastVerifyOps) / elapsed
+	instantDoneQPS := float64(totalOps+currentVerifyOps-
+		sc.lastTotalOps-sc.lastVerifyOps) / elapsed
+
+	// 计算批量操作瞬时写入行数速率
+	currentBatchRWRows := atomic.LoadInt64(&sc.batchRWRows)
+	instantBatchRowsPerSec := float64(currentBatchRWRows-sc.lastBatchRWRows) / elapsed
+
+	// 计算瞬时负载发送速率
+	currentTotalBytes := sc.getTotalBytesSent()
+	instantSendKBps := float64(currentTotalBytes-sc.lastTotalBytes) / 1024 / elapsed
 
 	// 计算平均速率
 	avgSendQPS := float64(totalSent) / totalElapsed
@@ -399,30 +716,67 @@ func (sc *Collector) PrintRealtime() {
 
 	// 获取延迟统计
 	sensorDataAvgLatency, _, _, _ := sc.sensorDataStats.GetStats()
+	sensorRWAvgLatency, _, _, _ := sc.sensorRWStats.GetStats()
+	batchRWAvgLatency, _, _, _ := sc.batchRWStats.GetStats()
+	queryAvgLatency, _, _, _ := sc.queryStats.GetStats()
 	verifyAvgLatency, _, _, _ := sc.verifyStats.GetStats()
 
 	// 获取高优先级请求延迟统计
 	sensorDataHighAvgLatency, _, _, _, sensorDataHighCount := sc.sensorDataStats.GetHighPriorityStats()
+	sensorRWHighAvgLatency, _, _, _, sensorRWHighCount := sc.sensorRWStats.GetHighPriorityStats()
 	verifyHighAvgLatency, _, _, _, verifyHighCount := sc.verifyStats.GetHighPriorityStats()
 
-	fmt.Printf("[%.1fs] 发送QPS: %.1f | 完成QPS: %.1f | 平均发送: %.1f | 平均完成: %.1f | 待处理: %d | 错误: %d\n",
-		totalElapsed, instantSendQPS, instantDoneQPS, avgSendQPS, avgDoneQPS, pending, totalErrors)
-	fmt.Printf("       延迟(ms): 上报%.1f 验证%.1f\n",
-		sensorDataAvgLatency, verifyAvgLatency)
+	fmt.Printf("[%.1fs] 发送QPS: %.1f | 完成QPS: %.1f | 平均发送: %.1f | 平均完成: %.1f | 待处理: %d | 错误: %d | 负载: %.1fKB/s\n",
+		totalElapsed, instantSendQPS, instantDoneQPS, avgSendQPS, avgDoneQPS, pending, totalErrors, instantSendKBps)
+	fmt.Printf("       延迟(ms): 上报%.1f 读写%.1f 批量%.1f 查询%.1f 验证%.1f | 批量行/秒: %.1f\n",
+		sensorDataAvgLatency, sensorRWAvgLatency, batchRWAvgLatency, queryAvgLatency, verifyAvgLatency,
+		instantBatchRowsPerSec)
+	fmt.Printf("       P50/P90/P95/P99/P99.9(ms): 上报%s 读写%s 批量%s 查询%s\n",
+		formatTailLatency(sc.sensorDataStats), formatTailLatency(sc.sensorRWStats),
+		formatTailLatency(sc.batchRWStats), formatTailLatency(sc.queryStats))
+	fmt.Printf("       响应时间P50/P90/P95/P99/P99.9(ms): 上报%s 读写%s 批量%s 查询%s\n",
+		formatTailLatency(sc.sensorDataResponseStats), formatTailLatency(sc.sensorRWResponseStats),
+		formatTailLatency(sc.batchRWResponseStats), formatTailLatency(sc.queryResponseStats))
+
+	// 显示本周期新增错误的分类
+	currentErrorKinds := sc.errorKinds.snapshot()
+	var instantErrorKinds [numErrorKinds]int64
+	for i := range instantErrorKinds {
+		instantErrorKinds[i] = currentErrorKinds[i] - sc.lastErrorKinds[i]
+	}
+	if summary := formatErrorKinds(instantErrorKinds); summary != "" {
+		fmt.Printf("       本周期错误: %s\n", summary)
+	}
 
 	// 显示高优先级请求统计
-	totalHighPriorityCount := sensorDataHighCount + verifyHighCount
+	totalHighPriorityCount := sensorDataHighCount + sensorRWHighCount + verifyHighCount
 	if totalHighPriorityCount > 0 {
-		fmt.Printf("       高优先级延迟(ms): 上报%.1f(%d) 验证%.1f(%d)\n",
+		fmt.Printf("       高优先级延迟(ms): 上报%.1f(%d) 读写%.1f(%d) 验证%.1f(%d)\n",
 			sensorDataHighAvgLatency, sensorDataHighCount,
+			sensorRWHighAvgLatency, sensorRWHighCount,
 			verifyHighAvgLatency, verifyHighCount)
 	}
 
+	currentVerifyErrors := atomic.LoadInt64(&sc.verifyErrors)
+	sc.recordTimeline(TimelinePoint{
+		Elapsed:      totalElapsed,
+		SentQPS:      instantSendQPS,
+		DoneQPS:      instantDoneQPS,
+		Errors:       totalErrors - sc.lastTotalErrors,
+		Pending:      pending,
+		VerifyErrors: currentVerifyErrors - sc.lastVerifyErrors,
+	})
+
 	// 更新上次统计
-	sc.lastSensorDataSent = currentSensorDataSent
+	sc.lastTotalErrors = totalErrors
+	sc.lastVerifyErrors = currentVerifyErrors
+	sc.lastTotalSent = totalSent
 	sc.lastVerifySent = currentVerifySent
-	sc.lastSensorDataOps = currentSensorDataOps
+	sc.lastTotalOps = totalOps
 	sc.lastVerifyOps = currentVerifyOps
+	sc.lastBatchRWRows = currentBatchRWRows
+	sc.lastTotalBytes = currentTotalBytes
+	sc.lastErrorKinds = currentErrorKinds
 	sc.lastPrintTime = now
 }
 
@@ -438,18 +792,31 @@ func (sc *Collector) PrintFinalReport() {
 	fmt.Printf("发送请求数: %d\n", totalSent)
 	fmt.Printf("完成请求数: %d\n", totalOps)
 	fmt.Printf("  传感器数据上报: %d (错误: %d)\n", atomic.LoadInt64(&sc.sensorDataOps), atomic.LoadInt64(&sc.sensorDataErrors))
+	fmt.Printf("  传感器读写操作: %d (错误: %d, 校验失败: %d)\n", atomic.LoadInt64(&sc.sensorRWOps), atomic.LoadInt64(&sc.sensorRWErrors),
+		atomic.LoadInt64(&sc.sensorRWValidationErrors))
+	fmt.Printf("  批量操作: %d (错误: %d, 校验失败: %d, 写入行数: %d)\n", atomic.LoadInt64(&sc.batchRWOps), atomic.LoadInt64(&sc.batchRWErrors),
+		atomic.LoadInt64(&sc.batchRWValidationErrors), atomic.LoadInt64(&sc.batchRWRows))
+	fmt.Printf("  查询操作: %d (错误: %d, 校验失败: %d, 分页重叠记录: %d)\n", atomic.LoadInt64(&sc.queryOps), atomic.LoadInt64(&sc.queryErrors),
+		atomic.LoadInt64(&sc.queryValidationErrors), atomic.LoadInt64(&sc.queryPageOverlaps))
 	fmt.Printf("  验证操作: %d (错误: %d)\n", atomic.LoadInt64(&sc.verifyOps), atomic.LoadInt64(&sc.verifyErrors))
+	fmt.Printf("  持久化探测: 可见 %d, 不可见 %d, 落盘超时 %d\n", atomic.LoadInt64(&sc.persistenceStats.totalCount),
+		atomic.LoadInt64(&sc.persistenceNotVisible), atomic.LoadInt64(&sc.saveDelayErrors))
 	fmt.Printf("待处理请求: %d\n", pending)
 	fmt.Printf("总错误数: %d\n", totalErrors)
+	sc.errorKinds.print()
+	fmt.Printf("发送负载字节数: %d (上报: %d, 读写: %d, 批量: %d)\n", sc.getTotalBytesSent(),
+		atomic.LoadInt64(&sc.sensorDataBytes), atomic.LoadInt64(&sc.sensorRWBytes), atomic.LoadInt64(&sc.batchRWBytes))
 
 	// 显示高优先级请求统计
 	_, _, _, _, sensorDataHighCount := sc.sensorDataStats.GetHighPriorityStats()
+	_, _, _, _, sensorRWHighCount := sc.sensorRWStats.GetHighPriorityStats()
 	_, _, _, _, verifyHighCount := sc.verifyStats.GetHighPriorityStats()
-	totalHighPriorityCount := sensorDataHighCount + verifyHighCount
+	totalHighPriorityCount := sensorDataHighCount + sensorRWHighCount + verifyHighCount
 
 	if totalHighPriorityCount > 0 {
 		fmt.Printf("\n高优先级请求 (Priority≥3) 统计:\n")
 		fmt.Printf("  传感器数据上报: %d\n", sensorDataHighCount)
+		fmt.Printf("  传感器读写操作: %d\n", sensorRWHighCount)
 		fmt.Printf("  验证操作: %d\n", verifyHighCount)
 		fmt.Printf("  高优先级请求总数: %d (占比: %.1f%%)\n", totalHighPriorityCount, float64(totalHighPriorityCount)*100/float64(totalOps))
 	}
@@ -460,6 +827,10 @@ func (sc *Collector) PrintFinalReport() {
 		if totalOps+totalErrors > 0 {
 			fmt.Printf("错误率: %.2f%%\n", float64(totalErrors)*100/float64(totalOps+totalErrors))
 		}
+		fmt.Printf("平均负载发送速率: %.2f KB/s\n", float64(sc.getTotalBytesSent())/1024/totalElapsed)
+		if batchRWRows := atomic.LoadInt64(&sc.batchRWRows); batchRWRows > 0 {
+			fmt.Printf("批量写入行/秒: %.2f\n", float64(batchRWRows)/totalElapsed)
+		}
 		if atomic.LoadInt64(&sc.verifyOps) > 0 {
 			fmt.Printf("验证错误率: %.2f%%\n", float64(atomic.LoadInt64(&sc.verifyErrors))*100/float64(atomic.LoadInt64(&sc.verifyOps)))
 		}
@@ -468,8 +839,29 @@ func (sc *Collector) PrintFinalReport() {
 	fmt.Println("\n=== 延迟分析 ===")
 	fmt.Println("传感器数据上报:")
 	sc.sensorDataStats.PrintDistribution()
+	fmt.Println("\n传感器读写操作:")
+	sc.sensorRWStats.PrintDistribution()
+	fmt.Println("\n批量操作:")
+	sc.batchRWStats.PrintDistribution()
+	fmt.Println("\n查询操作:")
+	sc.queryStats.PrintDistribution()
 	fmt.Println("\n验证操作:")
 	sc.verifyStats.PrintDistribution()
+	fmt.Println("\n=== 响应时间分析（从计划发送时刻计算，包含客户端排队延迟）===")
+	fmt.Println("传感器数据上报:")
+	sc.sensorDataResponseStats.PrintDistribution()
+	fmt.Println("\n传感器读写操作:")
+	sc.sensorRWResponseStats.PrintDistribution()
+	fmt.Println("\n批量操作:")
+	sc.batchRWResponseStats.PrintDistribution()
+	fmt.Println("\n查询操作:")
+	sc.queryResponseStats.PrintDistribution()
+
+	fmt.Println("\n持久化延迟（写入确认到数据可见）:")
+	sc.persistenceStats.PrintDistribution()
+
+	sc.printStages()
+	sc.outage.print()
 }
 
 // GetStatsReport 生成符合 model.StatsReport 格式的统计报告，用于数据上报
@@ -480,16 +872,31 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 
 	// 获取各操作类型的统计数据
 	sensorDataOps := atomic.LoadInt64(&sc.sensorDataOps)
-	sensorDataErrors := atomic.LoadInt64(&sc.sensorDataErrors)
+	sensorRWOps := atomic.LoadInt64(&sc.sensorRWOps)
+	sensorRWValidationErrors := atomic.LoadInt64(&sc.sensorRWValidationErrors)
+	batchRWOps := atomic.LoadInt64(&sc.batchRWOps)
+	batchRWRows := atomic.LoadInt64(&sc.batchRWRows)
+	batchRWValidationErrors := atomic.LoadInt64(&sc.batchRWValidationErrors)
+	queryOps := atomic.LoadInt64(&sc.queryOps)
+	queryValidationErrors := atomic.LoadInt64(&sc.queryValidationErrors)
+	queryPageOverlaps := atomic.LoadInt64(&sc.queryPageOverlaps)
+	sensorDataBytes := atomic.LoadInt64(&sc.sensorDataBytes)
+	sensorRWBytes := atomic.LoadInt64(&sc.sensorRWBytes)
+	batchRWBytes := atomic.LoadInt64(&sc.batchRWBytes)
+	totalBytesSent := sensorDataBytes + sensorRWBytes + batchRWBytes
 
 	// 计算性能指标
 	avgSentQPS := float32(0)
 	avgCompletedQPS := float32(0)
+	batchRowsPerSecond := float32(0)
+	avgSentBytesPerSecond := float32(0)
 	errorRate := float32(0)
 
 	if totalElapsed > 0 {
 		avgSentQPS = float32(totalSent) / float32(totalElapsed)
 		avgCompletedQPS = float32(totalOps) / float32(totalElapsed)
+		batchRowsPerSecond = float32(batchRWRows) / float32(totalElapsed)
+		avgSentBytesPerSecond = float32(totalBytesSent) / float32(totalElapsed)
 	}
 
 	if totalOps+totalErrors > 0 {
@@ -500,20 +907,54 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 	operationsStats := model.OperationsStats{
 		SensorData: model.OperationStat{
 			Operations: sensorDataOps,
-			Errors:     sensorDataErrors,
+			Errors:     atomic.LoadInt64(&sc.sensorDataErrors),
+			BytesSent:  &sensorDataBytes,
+		},
+		SensorRW: model.OperationStat{
+			Operations:       sensorRWOps,
+			Errors:           atomic.LoadInt64(&sc.sensorRWErrors),
+			ValidationErrors: &sensorRWValidationErrors,
+			BytesSent:        &sensorRWBytes,
+		},
+		BatchRW: model.OperationStat{
+			Operations:       batchRWOps,
+			Errors:           atomic.LoadInt64(&sc.batchRWErrors),
+			ValidationErrors: &batchRWValidationErrors,
+			Rows:             &batchRWRows,
+			BytesSent:        &batchRWBytes,
+		},
+		Query: model.OperationStat{
+			Operations:       queryOps,
+			Errors:           atomic.LoadInt64(&sc.queryErrors),
+			ValidationErrors: &queryValidationErrors,
+			PageOverlaps:     &queryPageOverlaps,
 		},
 	}
 
 	// 构建延迟分析
 	latencyAnalysis := model.LatencyAnalysis{
 		SensorData: sc.buildLatencyDistribution(sc.sensorDataStats),
+		SensorRW:   sc.buildLatencyDistribution(sc.sensorRWStats),
+		BatchRW:    sc.buildLatencyDistribution(sc.batchRWStats),
+		Query:      sc.buildLatencyDistribution(sc.queryStats),
+	}
+	latencyAnalysis.ResponseTime = &model.ResponseTimeAnalysis{
+		SensorData: sc.buildLatencyDistribution(sc.sensorDataResponseStats),
+		SensorRW:   sc.buildLatencyDistribution(sc.sensorRWResponseStats),
+		BatchRW:    sc.buildLatencyDistribution(sc.batchRWResponseStats),
+		Query:      sc.buildLatencyDistribution(sc.queryResponseStats),
+	}
+	if atomic.LoadInt64(&sc.persistenceStats.totalCount) > 0 {
+		persistence := sc.buildLatencyDistribution(sc.persistenceStats)
+		latencyAnalysis.Persistence = &persistence
 	}
 
 	// 构建高优先级请求统计
 	var highPriorityStats *model.HighPriorityStats
 	_, _, _, _, sensorDataHighCount := sc.sensorDataStats.GetHighPriorityStats()
+	_, _, _, _, sensorRWHighCount := sc.sensorRWStats.GetHighPriorityStats()
 
-	totalHighPriorityCount := sensorDataHighCount
+	totalHighPriorityCount := sensorDataHighCount + sensorRWHighCount
 
 	if totalHighPriorityCount > 0 {
 		percentage := float32(0)
@@ -524,6 +965,7 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 		highPriorityStats = &model.HighPriorityStats{
 			TotalCount:      totalHighPriorityCount,
 			SensorDataCount: sensorDataHighCount,
+			SensorRWCount:   sensorRWHighCount,
 			Percentage:      percentage,
 		}
 	}
@@ -534,11 +976,20 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 	totalLatencyCount := int64(0)
 
 	// 汇总所有操作类型的延迟统计
-	sensorDataAvg, _, _, _ := sc.sensorDataStats.GetStats()
-
-	if sensorDataOps > 0 {
-		totalLatencySum += sensorDataAvg * float64(sensorDataOps)
-		totalLatencyCount += sensorDataOps
+	for _, op := range []struct {
+		stats *LatencyStats
+		ops   int64
+	}{
+		{sc.sensorDataStats, sensorDataOps},
+		{sc.sensorRWStats, sensorRWOps},
+		{sc.batchRWStats, batchRWOps},
+		{sc.queryStats, queryOps},
+	} {
+		if op.ops > 0 {
+			avg, _, _, _ := op.stats.GetStats()
+			totalLatencySum += avg * float64(op.ops)
+			totalLatencyCount += op.ops
+		}
 	}
 
 	if totalLatencyCount > 0 {
@@ -551,17 +1002,12 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 	highPriorityLatencyCount := int64(0)
 
 	// 汇总所有操作类型的高优先级延迟统计
-	sensorDataHighAvg, _, _, _, sensorDataHighOps := sc.sensorDataStats.GetHighPriorityStats()
-	verifyHighAvg, _, _, _, verifyHighOps := sc.verifyStats.GetHighPriorityStats()
-
-	if sensorDataHighOps > 0 {
-		highPriorityLatencySum += sensorDataHighAvg * float64(sensorDataHighOps)
-		highPriorityLatencyCount += sensorDataHighOps
-	}
-
-	if verifyHighOps > 0 {
-		highPriorityLatencySum += verifyHighAvg * float64(verifyHighOps)
-		highPriorityLatencyCount += verifyHighOps
+	for _, latencyStats := range []*LatencyStats{sc.sensorDataStats, sc.sensorRWStats, sc.verifyStats} {
+		highAvg, _, _, _, highOps := latencyStats.GetHighPriorityStats()
+		if highOps > 0 {
+			highPriorityLatencySum += highAvg * float64(highOps)
+			highPriorityLatencyCount += highOps
+		}
 	}
 
 	if highPriorityLatencyCount > 0 {
@@ -579,6 +1025,7 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 	report := &model.StatsReport{
 		TotalElapsed:                float32(totalElapsed),
 		TotalSent:                   totalSent,
+		TotalBytesSent:              &totalBytesSent,
 		TotalOps:                    totalOps,
 		TotalErrors:                 totalErrors,
 		TotalAvgLatency:             &totalAvgLatencyF32,
@@ -588,12 +1035,22 @@ func (sc *Collector) GetStatsReport() *model.StatsReport {
 		Operations:                  operationsStats,
 		LatencyAnalysis:             latencyAnalysis,
 		PerformanceMetrics: model.PerformanceMetrics{
-			AvgSentQPS:      avgSentQPS,
-			AvgCompletedQPS: avgCompletedQPS,
-			ErrorRate:       errorRate,
+			AvgSentQPS:            avgSentQPS,
+			AvgCompletedQPS:       avgCompletedQPS,
+			BatchRowsPerSecond:    &batchRowsPerSecond,
+			AvgSentBytesPerSecond: &avgSentBytesPerSecond,
+			ErrorRate:             errorRate,
 		},
 		HighPriorityStats:    highPriorityStats,
-		TotalSaveDelayErrors: 0, // 目前没有追踪这个指标，设为0
+		ErrorBreakdown:       sc.errorKinds.report(),
+		Restart:              sc.outage.report(),
+		TotalSaveDelayErrors: atomic.LoadInt64(&sc.saveDelayErrors),
+	}
+	if stages := sc.Stages(); len(stages) > 0 {
+		report.Stages = &stages
+	}
+	if timeline := sc.buildTimeline(); len(timeline) > 0 {
+		report.Timeline = &timeline
 	}
 
 	return report
@@ -607,10 +1064,11 @@ func (sc *Collector) buildLatencyDistribution(stats *LatencyStats) model.Latency
 	highAvg, highMax, highMin, highBuckets, highCount := stats.GetHighPriorityStats()
 
 	dist := model.LatencyDistribution{
-		Avg:     float32(avg),
-		Max:     float32(max),
-		Min:     float32(min),
-		Buckets: buckets,
+		Avg:         float32(avg),
+		Max:         float32(max),
+		Min:         float32(min),
+		Buckets:     buckets,
+		Percentiles: stats.GetPercentiles().ToModel(),
 	}
 
 	// 如果有高优先级请求，添加高优先级统计
@@ -624,6 +1082,7 @@ func (sc *Collector) buildLatencyDistribution(stats *LatencyStats) model.Latency
 		dist.HighPriorityMin = &highMinF32
 		dist.HighPriorityBuckets = &highBuckets
 		dist.HighPriorityCount = &highCount
+		dist.HighPriorityPercentiles = stats.GetHighPriorityPercentiles().ToModel()
 	}
 
 	return dist
@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"sync"
+
+	"splay/model"
+)
+
+// timelineCapacity 时间线最多保留的周期数，超过后覆盖最早的周期
+// 默认每秒一个周期时可以保留最近一小时
+const timelineCapacity = 3600
+
+// TimelinePoint 一个统计周期的快照
+type TimelinePoint struct {
+	Elapsed      float64 // 周期结束时距压测开始的时间（秒）
+	SentQPS      float64 // 周期内的发送速率
+	DoneQPS      float64 // 周期内的完成速率
+	Errors       int64   // 周期内失败的业务请求数
+	Pending      int64   // 周期结束时的待处理请求数
+	VerifyErrors int64   // 周期内失败的验证请求数
+	Percentiles          // 周期内完成的业务请求的延迟百分位数（毫秒）
+}
+
+// timeline 按统计周期保存快照的环形缓冲区
+type timeline struct {
+	mu     sync.Mutex
+	points []TimelinePoint
+	next   int // 下一个写入位置
+	full   bool
+
+	// 上个周期结束时各业务操作直方图的累计计数之和，用于计算周期内的百分位数
+	lastLatencyCounts []int64
+}
+
+func (t *timeline) add(p TimelinePoint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.points == nil {
+		t.points = make([]TimelinePoint, timelineCapacity)
+	}
+	t.points[t.next] = p
+	t.next = (t.next + 1) % timelineCapacity
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// snapshot 按时间顺序获取保存的快照
+func (t *timeline) snapshot() []TimelinePoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]TimelinePoint(nil), t.points[:t.next]...)
+	}
+	points := make([]TimelinePoint, 0, timelineCapacity)
+	points = append(points, t.points[t.next:]...)
+	return append(points, t.points[:t.next]...)
+}
+
+// recordTimeline 记录一个统计周期的快照，由 PrintRealtime 在每个周期调用
+func (sc *Collector) recordTimeline(p TimelinePoint) {
+	counts := sc.latencyCounts()
+	p.Percentiles = intervalPercentiles(counts, sc.timeline.lastLatencyCounts)
+	sc.timeline.lastLatencyCounts = counts
+	sc.timeline.add(p)
+}
+
+// Timeline 获取按时间顺序排列的各统计周期快照
+func (sc *Collector) Timeline() []TimelinePoint {
+	return sc.timeline.snapshot()
+}
+
+// buildTimeline 生成时间线报告
+func (sc *Collector) buildTimeline() []model.TimelinePoint {
+	points := sc.Timeline()
+	result := make([]model.TimelinePoint, len(points))
+	for i, p := range points {
+		result[i] = model.TimelinePoint{
+			Elapsed:      float32(p.Elapsed),
+			SentQPS:      float32(p.SentQPS),
+			CompletedQPS: float32(p.DoneQPS),
+			Errors:       p.Errors,
+			Pending:      p.Pending,
+			VerifyErrors: p.VerifyErrors,
+			Percentiles:  *p.Percentiles.ToModel(),
+		}
+	}
+	return result
+}
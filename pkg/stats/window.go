@@ -0,0 +1,43 @@
+package stats
+
+import "time"
+
+// WindowStats 一个统计窗口内的业务请求统计
+type WindowStats struct {
+	Elapsed      time.Duration // 窗口时长
+	Sent         int64         // 窗口内发送的请求数
+	Completed    int64         // 窗口内成功完成的请求数
+	Errors       int64         // 窗口内失败的请求数
+	Pending      int64         // 窗口结束时已发送但尚未完成的请求数
+	Latency      Percentiles   // 窗口内完成的请求的服务时间百分位数
+	ResponseTime Percentiles   // 窗口内完成的请求的响应时间百分位数
+}
+
+// Window 连续的统计窗口，每次调用 Next 返回自上次调用以来的统计，用于闭环速率控制
+// Window 不是并发安全的，应由单个goroutine使用
+type Window struct {
+	sc   *Collector
+	last stageMark
+}
+
+// NewWindow 创建从当前时刻开始的统计窗口
+func (sc *Collector) NewWindow() *Window {
+	return &Window{sc: sc, last: sc.markStage()}
+}
+
+// Next 结束当前窗口并返回其统计，同时开始下一个窗口
+func (w *Window) Next() WindowStats {
+	mark := w.sc.markStage()
+	last := w.last
+	w.last = mark
+
+	return WindowStats{
+		Elapsed:      mark.time.Sub(last.time),
+		Sent:         mark.sent - last.sent,
+		Completed:    mark.ops - last.ops,
+		Errors:       mark.errors - last.errors,
+		Pending:      mark.sent - mark.ops - mark.errors,
+		Latency:      intervalPercentiles(mark.latencyCounts, last.latencyCounts),
+		ResponseTime: intervalPercentiles(mark.responseCounts, last.responseCounts),
+	}
+}
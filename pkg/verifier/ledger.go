@@ -0,0 +1,243 @@
+package verifier
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 写入指纹的组成（十六进制）：16位运行ID + 5位Worker ID + 7位Worker内序号
+const (
+	fingerprintLen = 28
+	maxWorkerID    = 1<<20 - 1 // 指纹可区分的最大Worker ID，配置校验保证Worker数不超过它
+	seqMask        = 1<<28 - 1 // Worker内序号的取值范围，单个Worker写入超过2.6亿次后序号回绕
+)
+
+// runMarkerDevice 运行标记行的设备ID，标记行的指标名为运行ID
+const runMarkerDevice = "splay-run"
+
+// Ledger 写入账本
+// 为每次写入生成本次运行内唯一的指纹（嵌入在 data 负载末尾），并把服务端确认的写入追加到磁盘文件，
+// 压测结束后与 time_series_data 对账，得到确认写入但未落库的准确数量
+type Ledger struct {
+	runID string // 本次运行的ID，所有指纹以它开头
+
+	path   string
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	closed bool // 关闭后到达的确认写入不再记录
+}
+
+// NewLedger 创建写入账本，path 为空时只生成指纹，不记录账本也不对账
+// 运行ID由种子和运行序号决定，指纹由运行ID、Worker ID和Worker内序号组成：
+// 并发模式下每个Worker依次发送，相同种子和序号的两次运行发送的负载完全相同；
+// QPS模式下同一Worker的并发请求取得序号的先后不确定，两次运行产生的指纹集合相同，但携带某个指纹的请求可能不同
+func NewLedger(path string, seed int64, run int) (*Ledger, error) {
+	l := &Ledger{
+		runID: runID(seed, run),
+		path:  path,
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建写入账本失败: %v", err)
+	}
+	l.file = file
+	l.writer = bufio.NewWriter(file)
+	return l, nil
+}
+
+// runID 由种子和运行序号派生运行ID（splitmix64）
+func runID(seed int64, run int) string {
+	z := uint64(seed) ^ uint64(run+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return fmt.Sprintf("%016x", z^(z>>31))
+}
+
+// Fingerprint 生成 worker 的第 seq 次写入的指纹
+func (l *Ledger) Fingerprint(worker int, seq uint64) string {
+	return fmt.Sprintf("%s%05x%07x", l.runID, worker&maxWorkerID, seq&seqMask)
+}
+
+// Claim 检查 time_series_data 中是否已有本次运行ID的标记行，没有时写入标记行，应在压测开始前调用
+// 相同种子和运行序号的上一次运行写入的数据与本次写入的指纹相同，对账时会被误认为本次写入已落库；
+// 标记行按 (device_id, metric_name) 索引查找，不扫描负载，其 data 为空，不会被对账匹配
+func (l *Ledger) Claim(ctx context.Context, db *sql.DB) (used bool, err error) {
+	err = db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM time_series_data WHERE device_id = ? AND metric_name = ?)`,
+		runMarkerDevice, l.runID).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("查询运行标记失败: %v", err)
+	}
+	if used {
+		return true, nil
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO time_series_data (timestamp, device_id, metric_name, value, data) VALUES (NOW(3), ?, ?, 0, '')`,
+		runMarkerDevice, l.runID)
+	if err != nil {
+		return false, fmt.Errorf("写入运行标记失败: %v", err)
+	}
+	return false, nil
+}
+
+// Record 记录一次服务端确认的写入，ackTime 为收到确认的时刻
+func (l *Ledger) Record(fingerprint, deviceID, metricName string, ackTime time.Time) {
+	if l.writer == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	fmt.Fprintf(l.writer, "%s\t%d\t%s\t%s\n", fingerprint, ackTime.UnixMilli(), deviceID, metricName)
+}
+
+// Close 将账本刷新到磁盘并关闭文件
+func (l *Ledger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if err := l.writer.Flush(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("写入账本失败: %v", err)
+	}
+	return l.file.Close()
+}
+
+// LossResult 数据丢失对账结果
+type LossResult struct {
+	Acknowledged int64    // 账本中确认写入的数量
+	Lost         int64    // 确认写入但 time_series_data 中不存在的数量
+	Samples      []string // 部分丢失写入的明细（指纹 确认时刻 设备 指标），便于排查
+
+	lostAckTimes []int64 // 丢失写入的确认时刻（Unix毫秒）
+}
+
+// maxLossSamples 对账结果中保留的丢失写入明细数量
+const maxLossSamples = 5
+
+// LossRate 获取数据丢失率（%）
+func (r *LossResult) LossRate() float64 {
+	if r.Acknowledged == 0 {
+		return 0
+	}
+	return float64(r.Lost) * 100 / float64(r.Acknowledged)
+}
+
+// LostBefore 获取在 t 之前已确认的丢失写入数
+func (r *LossResult) LostBefore(t time.Time) int64 {
+	var lost int64
+	for _, ackTime := range r.lostAckTimes {
+		if ackTime < t.UnixMilli() {
+			lost++
+		}
+	}
+	return lost
+}
+
+// Reconcile 读取账本并与 time_series_data 对账，应在 Close 之后调用
+// 通过一次按运行ID匹配负载末尾的扫描取出本次运行写入的全部指纹，避免逐条查询
+func (l *Ledger) Reconcile(ctx context.Context, db *sql.DB) (*LossResult, error) {
+	if l.path == "" {
+		return nil, fmt.Errorf("未配置写入账本文件")
+	}
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取写入账本失败: %v", err)
+	}
+	defer file.Close()
+
+	pending := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fingerprint, _, _ := strings.Cut(line, "\t")
+		pending[fingerprint] = line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取写入账本失败: %v", err)
+	}
+
+	result := &LossResult{Acknowledged: int64(len(pending))}
+
+	rows, err := db.QueryContext(ctx, `SELECT RIGHT(data, ?) FROM time_series_data WHERE data LIKE ?`,
+		fingerprintLen, l.pattern())
+	if err != nil {
+		return nil, fmt.Errorf("查询写入指纹失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fingerprint string
+		if err := rows.Scan(&fingerprint); err != nil {
+			return nil, fmt.Errorf("查询写入指纹失败: %v", err)
+		}
+		delete(pending, fingerprint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询写入指纹失败: %v", err)
+	}
+
+	result.Lost = int64(len(pending))
+	for _, line := range pending {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		ackTime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("写入账本格式错误: %s", line)
+		}
+		result.lostAckTimes = append(result.lostAckTimes, ackTime)
+
+		if len(result.Samples) < maxLossSamples {
+			fields[1] = time.UnixMilli(ackTime).Format("15:04:05.000")
+			result.Samples = append(result.Samples, strings.Join(fields, " "))
+		}
+	}
+
+	return result, nil
+}
+
+// Print 打印数据丢失对账结果
+func (r *LossResult) Print() {
+	fmt.Printf("\n=== 数据丢失对账 ===\n")
+	fmt.Printf("确认写入数: %d\n", r.Acknowledged)
+	fmt.Printf("丢失写入数: %d\n", r.Lost)
+	fmt.Printf("数据丢失率: %.4f%%\n", r.LossRate())
+	for _, sample := range r.Samples {
+		fmt.Printf("  丢失: %s\n", sample)
+	}
+}
+
+// pattern 匹配本次运行写入负载的 LIKE 模式：以运行ID开头、后跟Worker ID和序号的指纹位于负载末尾
+func (l *Ledger) pattern() string {
+	return "%" + l.runID + strings.Repeat("_", fingerprintLen-len(l.runID))
+}
+
+// EmbedFingerprint 用指纹替换 data 负载末尾的字节，保持负载长度不变；fingerprint 为空时原样返回
+// 配置校验保证需要指纹时负载不短于指纹，较短的负载只保留指纹
+func EmbedFingerprint(data, fingerprint string) string {
+	if len(data) <= len(fingerprint) {
+		return fingerprint
+	}
+	return data[:len(data)-len(fingerprint)] + fingerprint
+}
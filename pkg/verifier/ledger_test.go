@@ -0,0 +1,42 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLedgerFingerprint(t *testing.T) {
+	l, err := NewLedger("", 42, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for worker := range 3 {
+		for seq := uint64(1); seq <= 3; seq++ {
+			fp := l.Fingerprint(worker, seq)
+			if len(fp) != fingerprintLen || !strings.HasPrefix(fp, l.runID) {
+				t.Fatalf("Fingerprint(%d, %d) = %q, 长度或前缀错误", worker, seq, fp)
+			}
+			if seen[fp] {
+				t.Fatalf("Fingerprint(%d, %d) = %q 重复", worker, seq, fp)
+			}
+			seen[fp] = true
+		}
+	}
+
+	// 相同种子和运行序号下，指纹只由Worker ID和序号决定
+	again, _ := NewLedger("", 42, 0)
+	if got, want := again.Fingerprint(2, 3), l.Fingerprint(2, 3); got != want {
+		t.Errorf("相同种子的指纹 = %q, want %q", got, want)
+	}
+	other, _ := NewLedger("", 42, 1)
+	if other.Fingerprint(2, 3) == l.Fingerprint(2, 3) {
+		t.Errorf("不同运行序号的指纹相同")
+	}
+
+	// 超出范围的Worker ID和序号回绕，长度不变
+	if fp := l.Fingerprint(maxWorkerID+1, seqMask+1); len(fp) != fingerprintLen {
+		t.Errorf("回绕后的指纹长度 = %d, want %d", len(fp), fingerprintLen)
+	}
+}
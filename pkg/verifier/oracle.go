@@ -0,0 +1,329 @@
+package verifier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"splay/pkg/config"
+)
+
+// alertThreshold 业务告警阈值
+const alertThreshold = 100.0
+
+// Oracle 业务规则校验器
+// 只校验自身跟踪的写入（上报、读写和批量读写）：告警优先级按写入指纹筛选，不受其他客户端写入的数据影响
+type Oracle struct {
+	db          *sql.DB
+	pattern     string // 匹配本次运行写入负载的 LIKE 模式
+	table       *config.DeviceStatusConfig
+	unavailable string // device_status 校验不可用的原因，为空表示可用
+
+	mu       sync.Mutex
+	devices  map[string]*deviceState
+	baseline map[string]deviceBaseline // 压测开始前的 device_status
+	alerts   map[string]struct{}       // 确认写入的告警数据的指纹
+}
+
+// deviceBaseline 压测开始前一个设备的 device_status 记录
+type deviceBaseline struct {
+	alertCount sql.NullInt64
+	updatedAt  sql.NullFloat64 // 更新时间（Unix秒），由服务端的时钟决定，只与压测结束后的值比较
+}
+
+type deviceState struct {
+	inflight  int     // 正在进行中的写入数
+	version   uint64  // 每次开始写入时递增
+	lastValue float64 // 最后一次确认写入的数值
+	lastKnown bool    // 最新值是否可确定
+	alerts    int64   // 确认写入的告警次数
+	acked     bool    // 存在确认的写入，device_status 中必须有该设备的记录且更新时间晚于压测开始前
+	uncertain bool    // 存在失败的写入，告警计数不可确定
+}
+
+// Ticket 一次写入的登记凭证
+type Ticket struct {
+	deviceID    string
+	fingerprint string
+	version     uint64
+	exclusive   bool // 写入开始时该设备没有其他进行中的写入
+}
+
+// OracleResult 业务规则校验结果
+type OracleResult struct {
+	DeviceStatusUnavailable string // device_status 校验不可用的原因，为空表示已校验
+	CheckedDevices          int    // 参与校验的设备数
+	ValueMismatches         int64  // device_status 最新值不一致的设备数
+	AlertCountMismatches    int64  // device_status 告警计数不一致的设备数
+	UpdateTimeMismatches    int64  // device_status 更新时间没有晚于压测开始前的设备数
+	MissingDevices          int64  // 有确认写入但 device_status 中没有记录的设备数
+	PriorityViolations      int64  // 数值>100 但优先级不为1的数据行数
+}
+
+// Total 获取业务规则校验失败总数
+func (r *OracleResult) Total() int64 {
+	return r.ValueMismatches + r.AlertCountMismatches + r.UpdateTimeMismatches + r.MissingDevices + r.PriorityViolations
+}
+
+// Available 判断校验结果是否完整，device_status 校验不可用时失败总数不能代表业务规则是否满足
+func (r *OracleResult) Available() bool {
+	return r.DeviceStatusUnavailable == ""
+}
+
+// NewOracle 创建业务规则校验器，确认 device_status 表中存在配置的列并记录压测开始前的状态
+// pattern 为匹配本次运行写入负载的 LIKE 模式
+func NewOracle(ctx context.Context, db *sql.DB, pattern string, table *config.DeviceStatusConfig) (*Oracle, error) {
+	o := &Oracle{
+		db:       db,
+		pattern:  pattern,
+		table:    table,
+		devices:  make(map[string]*deviceState),
+		baseline: make(map[string]deviceBaseline),
+		alerts:   make(map[string]struct{}),
+	}
+
+	missing, err := missingColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		o.unavailable = fmt.Sprintf("%s 表中没有配置的列 %s", table.Table, strings.Join(missing, ", "))
+		fmt.Printf("警告: device_status 校验不可用: %s\n", o.unavailable)
+		return o, nil
+	}
+
+	rows, err := db.QueryContext(ctx, o.selectQuery(false))
+	if err != nil {
+		return nil, fmt.Errorf("读取 device_status 基线失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deviceID string
+		var b deviceBaseline
+		if err := rows.Scan(&deviceID, &b.alertCount, &b.updatedAt); err != nil {
+			return nil, fmt.Errorf("读取 device_status 基线失败: %v", err)
+		}
+		o.baseline[deviceID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取 device_status 基线失败: %v", err)
+	}
+
+	return o, nil
+}
+
+// missingColumns 从 information_schema 中找出 device_status 表中不存在的配置列，表不存在时返回全部配置的列
+func missingColumns(ctx context.Context, db *sql.DB, table *config.DeviceStatusConfig) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, table.Table)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 表结构失败: %v", table.Table, err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("读取 %s 表结构失败: %v", table.Table, err)
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取 %s 表结构失败: %v", table.Table, err)
+	}
+
+	var missing []string
+	for _, column := range []string{table.DeviceIDColumn, table.LastValueColumn, table.AlertCountColumn, table.UpdatedAtColumn} {
+		if column != "" && !existing[strings.ToLower(column)] {
+			missing = append(missing, column)
+		}
+	}
+	return missing, nil
+}
+
+// selectQuery 生成读取 device_status 的查询：设备ID、告警计数、更新时间（Unix秒），withLastValue 时追加最新值
+// 未配置的列以 NULL 代替
+func (o *Oracle) selectQuery(withLastValue bool) string {
+	column := func(name, expr string) string {
+		if name == "" {
+			return "NULL"
+		}
+		return fmt.Sprintf(expr, "`"+name+"`")
+	}
+	selected := []string{
+		column(o.table.DeviceIDColumn, "%s"),
+		column(o.table.AlertCountColumn, "%s"),
+		column(o.table.UpdatedAtColumn, "UNIX_TIMESTAMP(%s)"),
+	}
+	if withLastValue {
+		selected = append(selected, column(o.table.LastValueColumn, "%s"))
+	}
+	return "SELECT " + strings.Join(selected, ", ") + " FROM `" + o.table.Table + "`"
+}
+
+// Begin 登记一次写入开始，fingerprint 为写入负载中的指纹，Oracle 为 nil 时不做任何处理
+func (o *Oracle) Begin(deviceID, fingerprint string) Ticket {
+	if o == nil {
+		return Ticket{}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	d, ok := o.devices[deviceID]
+	if !ok {
+		d = &deviceState{}
+		o.devices[deviceID] = d
+	}
+	d.inflight++
+	d.version++
+
+	return Ticket{deviceID: deviceID, fingerprint: fingerprint, version: d.version, exclusive: d.inflight == 1}
+}
+
+// Finish 登记一次写入完成，acked 表示服务端确认写入成功
+func (o *Oracle) Finish(ticket Ticket, value float64, acked bool) {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	d := o.devices[ticket.deviceID]
+	d.inflight--
+
+	if !acked {
+		// 无法确定服务端是否已生效
+		d.uncertain = true
+		d.lastKnown = false
+		return
+	}
+
+	d.acked = true
+	if value > alertThreshold {
+		d.alerts++
+		o.alerts[ticket.fingerprint] = struct{}{}
+	}
+	if d.version == ticket.version {
+		d.lastValue = value
+		d.lastKnown = ticket.exclusive
+	} else {
+		// 写入与之后开始的写入交错，最终生效顺序无法确定
+		d.lastKnown = false
+	}
+}
+
+// Verify 查询MySQL校验业务规则，应在压测结束并等待数据落盘后调用
+func (o *Oracle) Verify(ctx context.Context) (*OracleResult, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	result := &OracleResult{}
+	if err := o.verifyDeviceStatus(ctx, result); err != nil {
+		return nil, err
+	}
+
+	// 数值>100 的数据必须以高优先级(1)存储，只检查本次运行确认的告警写入
+	rows, err := o.db.QueryContext(ctx, `SELECT RIGHT(data, ?), priority FROM time_series_data
+		WHERE data LIKE ? AND value > ?`, fingerprintLen, o.pattern, alertThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("查询告警数据优先级失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fingerprint string
+		var priority int
+		if err := rows.Scan(&fingerprint, &priority); err != nil {
+			return nil, fmt.Errorf("查询告警数据优先级失败: %v", err)
+		}
+		if _, ok := o.alerts[fingerprint]; ok && priority != 1 {
+			result.PriorityViolations++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询告警数据优先级失败: %v", err)
+	}
+
+	return result, nil
+}
+
+// verifyDeviceStatus 校验 device_status 的最新值、告警计数和更新时间，未配置的列跳过对应的校验
+func (o *Oracle) verifyDeviceStatus(ctx context.Context, result *OracleResult) error {
+	if o.unavailable != "" {
+		result.DeviceStatusUnavailable = o.unavailable
+		return nil
+	}
+
+	rows, err := o.db.QueryContext(ctx, o.selectQuery(true))
+	if err != nil {
+		return fmt.Errorf("查询 device_status 失败: %v", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool, len(o.devices))
+	for rows.Next() {
+		var deviceID string
+		var alertCount sql.NullInt64
+		var updatedAt, lastValue sql.NullFloat64
+		if err := rows.Scan(&deviceID, &alertCount, &updatedAt, &lastValue); err != nil {
+			return fmt.Errorf("查询 device_status 失败: %v", err)
+		}
+
+		d, ok := o.devices[deviceID]
+		if !ok || !d.acked {
+			continue
+		}
+		found[deviceID] = true
+		result.CheckedDevices++
+		baseline := o.baseline[deviceID]
+
+		if o.table.LastValueColumn != "" && d.lastKnown &&
+			(!lastValue.Valid || math.Abs(lastValue.Float64-d.lastValue) > 1e-9*math.Max(1, math.Abs(d.lastValue))) {
+			result.ValueMismatches++
+		}
+		if o.table.AlertCountColumn != "" && !d.uncertain &&
+			alertCount.Int64-baseline.alertCount.Int64 != d.alerts {
+			result.AlertCountMismatches++
+		}
+		// 更新时间由服务端的时钟决定，只与同一设备压测开始前的值比较，不受客户端与服务端时钟偏差影响
+		if o.table.UpdatedAtColumn != "" &&
+			(!updatedAt.Valid || baseline.updatedAt.Valid && updatedAt.Float64 <= baseline.updatedAt.Float64) {
+			result.UpdateTimeMismatches++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("查询 device_status 失败: %v", err)
+	}
+
+	// 有确认写入但 device_status 中没有记录的设备，单独计数，不与最新值或告警计数的不一致混在一起
+	for deviceID, d := range o.devices {
+		if found[deviceID] || !d.acked {
+			continue
+		}
+		result.CheckedDevices++
+		result.MissingDevices++
+	}
+	return nil
+}
+
+// Print 打印业务规则校验结果
+func (r *OracleResult) Print() {
+	fmt.Printf("\n=== 业务规则校验 ===\n")
+	if !r.Available() {
+		fmt.Printf("device_status 校验不可用: %s，报告中不给出业务规则校验失败总数\n", r.DeviceStatusUnavailable)
+	}
+	fmt.Printf("校验设备数: %d\n", r.CheckedDevices)
+	fmt.Printf("最新值不一致: %d\n", r.ValueMismatches)
+	fmt.Printf("告警计数不一致: %d\n", r.AlertCountMismatches)
+	fmt.Printf("更新时间未更新: %d\n", r.UpdateTimeMismatches)
+	fmt.Printf("缺少 device_status 记录: %d\n", r.MissingDevices)
+	fmt.Printf("告警数据优先级错误: %d\n", r.PriorityViolations)
+	fmt.Printf("业务规则校验失败总数: %d\n", r.Total())
+}
@@ -0,0 +1,94 @@
+package verifier
+
+import (
+	"testing"
+
+	"splay/pkg/config"
+)
+
+func TestOracleTracking(t *testing.T) {
+	type write struct {
+		value float64
+		acked bool
+	}
+	tests := []struct {
+		name          string
+		sequential    []write // 依次执行的写入
+		concurrent    []write // 同时进行的写入，在 sequential 之后执行
+		wantLast      float64
+		wantLastKnown bool
+		wantAlerts    int64
+		wantUncertain bool
+		wantAcked     bool
+	}{
+		{
+			name:          "依次确认的写入",
+			sequential:    []write{{50, true}, {150, true}, {80, true}},
+			wantLast:      80,
+			wantLastKnown: true,
+			wantAlerts:    1,
+			wantAcked:     true,
+		},
+		{
+			name:          "失败的写入使结果不可确定",
+			sequential:    []write{{120, true}, {130, false}},
+			wantLastKnown: false,
+			wantAlerts:    1,
+			wantUncertain: true,
+			wantAcked:     true,
+		},
+		{
+			name:          "并发写入的最新值不可确定",
+			sequential:    []write{{10, true}},
+			concurrent:    []write{{20, true}, {30, true}},
+			wantLastKnown: false,
+			wantAcked:     true,
+		},
+		{
+			name:          "没有确认的写入",
+			sequential:    []write{{10, false}},
+			wantUncertain: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Oracle{devices: make(map[string]*deviceState), alerts: make(map[string]struct{})}
+			for _, w := range tt.sequential {
+				o.Finish(o.Begin("d1", "fp"), w.value, w.acked)
+			}
+			tickets := make([]Ticket, len(tt.concurrent))
+			for i := range tt.concurrent {
+				tickets[i] = o.Begin("d1", "fp")
+			}
+			for i, w := range tt.concurrent {
+				o.Finish(tickets[i], w.value, w.acked)
+			}
+
+			d := o.devices["d1"]
+			if d.lastKnown != tt.wantLastKnown || (tt.wantLastKnown && d.lastValue != tt.wantLast) {
+				t.Errorf("lastValue=%v lastKnown=%v, want %v %v", d.lastValue, d.lastKnown, tt.wantLast, tt.wantLastKnown)
+			}
+			if d.alerts != tt.wantAlerts || d.uncertain != tt.wantUncertain || d.acked != tt.wantAcked {
+				t.Errorf("alerts=%d uncertain=%v acked=%v, want %d %v %v",
+					d.alerts, d.uncertain, d.acked, tt.wantAlerts, tt.wantUncertain, tt.wantAcked)
+			}
+		})
+	}
+}
+
+func TestOracleSelectQuery(t *testing.T) {
+	table := config.DeviceStatusConfig{Table: "device_status", DeviceIDColumn: "device_id", UpdatedAtColumn: "updated_at"}
+	o := &Oracle{table: &table}
+
+	want := "SELECT `device_id`, NULL, UNIX_TIMESTAMP(`updated_at`), NULL FROM `device_status`"
+	if got := o.selectQuery(true); got != want {
+		t.Errorf("selectQuery = %q, want %q", got, want)
+	}
+}
+
+func TestOracleResultAvailable(t *testing.T) {
+	r := OracleResult{DeviceStatusUnavailable: "device_status 表中没有配置的列 device_id", PriorityViolations: 0}
+	if r.Available() {
+		t.Errorf("device_status 校验不可用时 Available = true")
+	}
+}
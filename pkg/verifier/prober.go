@@ -0,0 +1,82 @@
+package verifier
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"splay/pkg/stats"
+)
+
+// 持久化探测的轮询间隔，从 minProbeInterval 开始逐次翻倍，最大为 maxProbeInterval
+const (
+	minProbeInterval = 10 * time.Millisecond
+	maxProbeInterval = 200 * time.Millisecond
+)
+
+// Prober 持久化探测器
+// 探测任务出队后按逐渐增大的间隔轮询MySQL，记录数据从写入确认到可见的时间（time-to-visible）；
+// 任务排队期间已可见的写入只能得到可见时间的上界，因此只有在确认后超过时限的时刻仍查询不到数据才计为落盘超时
+type Prober struct {
+	db             *sql.DB
+	sla            time.Duration
+	timeout        time.Duration
+	statsCollector *stats.Collector
+}
+
+// NewProber 创建持久化探测器，sla 为持久化时限，timeout 为最长探测时间
+func NewProber(db *sql.DB, sla, timeout time.Duration, statsCollector *stats.Collector) *Prober {
+	return &Prober{
+		db:             db,
+		sla:            sla,
+		timeout:        timeout,
+		statsCollector: statsCollector,
+	}
+}
+
+// Probe 探测一次已确认的写入，fingerprint 为嵌入在 data 负载末尾的写入指纹，
+// ackTime 为收到写入确认的时刻，应在独立的goroutine中调用
+func (p *Prober) Probe(deviceID, metricName, fingerprint string, priority int, ackTime time.Time) {
+	// 写入指纹在本次运行内唯一，不会被同一设备和指标之前的数据误匹配
+	query := `SELECT COUNT(*) FROM time_series_data
+		WHERE device_id = ? AND metric_name = ? AND RIGHT(data, ?) = ?`
+
+	deadline := ackTime.Add(p.timeout)
+	interval := minProbeInterval
+	queried := false   // 至少有一次查询成功，区分数据未落盘和MySQL不可用
+	slaMissed := false // 在确认后超过时限的时刻仍查询不到数据
+
+	for {
+		probeStart := time.Now()
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		var count int
+		err := p.db.QueryRowContext(ctx, query, deviceID, metricName, fingerprintLen, fingerprint).Scan(&count)
+		cancel()
+		queryLatency := time.Since(probeStart)
+
+		if err == nil {
+			queried = true
+			if count > 0 {
+				// 数据在本次查询开始前已可见，第一次查询即可见时这只是可见时间的上界
+				visibleAfter := probeStart.Sub(ackTime)
+				p.statsCollector.PushCompletedResult("verify-query", queryLatency, queryLatency, priority, true)
+				p.statsCollector.PushPersistenceResult(visibleAfter, priority, true, slaMissed)
+				return
+			}
+			if probeStart.Sub(ackTime) > p.sla {
+				slaMissed = true
+			}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			p.statsCollector.PushCompletedResult("verify-query", queryLatency, queryLatency, priority, false)
+			if queried {
+				p.statsCollector.PushPersistenceResult(p.timeout, priority, false, slaMissed)
+			}
+			return
+		}
+
+		time.Sleep(interval)
+		interval = min(interval*2, maxProbeInterval)
+	}
+}
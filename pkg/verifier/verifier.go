@@ -0,0 +1,244 @@
+// Package verifier 提供压测数据的持久化和业务规则校验功能
+//
+// 需求和预设:
+// 1. 业务规则: 数值>100 触发高优先级告警，存储的优先级为1，并更新服务端的 device_status（最新值、更新时间、告警计数）
+// 2. 写入跟踪: 按设备记录客户端收到确认的写入（包括上报写入），包括最新值和告警次数
+// 3. 事后校验: 压测结束后查询MySQL，确认 device_status 的最新值、告警计数和更新时间、以及本次运行跟踪的告警写入的优先级（表名和列名由配置给出，配置的表或列不存在时 device_status 校验不可用）
+// 4. 基线快照: 压测开始前记录 device_status 的告警计数和更新时间，校验时只比较本次压测产生的变化
+// 5. 不确定性处理: 同一设备上的并发写入或失败的写入会使结果不可确定，此时跳过对应的校验
+// 6. 持久化探测: 探测任务出队后按逐渐增大的间隔轮询数据是否可见，记录从确认到可见的时间分布，确认后超过时限仍不可见计为落盘超时，排队时间单独统计
+// 7. 数据丢失对账: 每次写入携带唯一指纹，确认的写入记录到磁盘账本，压测结束后统计确认写入但未落库的准确数量
+// 8. 有界校验: 按采样率抽取写入进行探测，探测任务进入有界队列由固定数量的校验协程执行，队列满时丢弃
+//
+// 设计原则:
+// - 跟踪所有写入接口（sensor-data、sensor-rw、batch-rw），它们写入相同的设备，都会影响 device_status
+// - 跟踪开销极小，不影响Worker的执行性能
+// - 使用一个长期存在、有连接数上限的数据库连接池，避免频繁建立连接
+// - 校验不能成为瓶颈: Worker提交探测任务不阻塞，丢弃数和连接池等待时间体现在报告中
+// - 校验失败不中断压测，只在报告中体现
+package verifier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"splay/model"
+	"splay/pkg/config"
+	"splay/pkg/stats"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Verifier 校验子系统
+// 持有唯一的MySQL连接池，管理持久化探测队列、写入账本和业务规则校验器
+type Verifier struct {
+	db     *sql.DB
+	config *config.VerifierConfig
+	oracle *Oracle // 未启用业务规则校验时为 nil
+	prober *Prober
+	ledger *Ledger
+
+	fingerprints bool // 写入是否携带指纹，未启用写入账本、持久化探测和业务规则校验时不携带
+
+	mu     sync.RWMutex // 保护 queue 的关闭，Drain 之后到达的确认写入不再提交探测
+	closed bool
+	queue  chan probeJob
+	wg     sync.WaitGroup
+
+	acked   atomic.Int64 // 确认的写入数，用于按采样率抽取
+	sampled atomic.Int64 // 抽中进行探测的写入数
+	dropped atomic.Int64 // 被丢弃的探测数（队列已满或排队超时）
+	expired atomic.Int64 // 在队列中等待超过最长探测时间而丢弃的探测数
+
+	queueWait *stats.Histogram // 探测任务从确认写入到出队的等待时间
+	timeout   time.Duration    // 最长探测时间
+}
+
+// probeJob 一次持久化探测任务
+type probeJob struct {
+	deviceID    string
+	metricName  string
+	fingerprint string
+	priority    int
+	ackTime     time.Time
+}
+
+// New 创建校验子系统并启动校验协程
+// 业务规则校验器创建失败（如MySQL不可用）时只打印警告，不影响压测
+func New(cfg *config.Config, statsCollector *stats.Collector) (*Verifier, error) {
+	db, err := sql.Open("mysql", cfg.MySQLDSN)
+	if err != nil {
+		return nil, fmt.Errorf("打开MySQL连接失败: %v", err)
+	}
+	db.SetMaxOpenConns(cfg.Verifier.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Verifier.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Verifier.GetConnMaxLifetime())
+
+	ledger, err := NewLedger(cfg.LedgerFile, cfg.Seed, cfg.RunIndex)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// 数据库中已有相同运行ID的标记行时拒绝启动，否则对账会漏报丢失；MySQL不可用时只打印警告
+	if cfg.LedgerFile != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		used, err := ledger.Claim(ctx, db)
+		cancel()
+		if err != nil {
+			fmt.Printf("警告: 无法检查数据库中是否已有本次运行ID的数据: %v\n", err)
+		} else if used {
+			ledger.Close()
+			db.Close()
+			return nil, fmt.Errorf("time_series_data 中已有 seed=%d、run_index=%d 的运行标记，对账会漏报丢失，请递增 run_index 或更换 seed",
+				cfg.Seed, cfg.RunIndex)
+		}
+	}
+
+	v := &Verifier{
+		db:     db,
+		config: &cfg.Verifier,
+		prober: NewProber(db, cfg.GetPersistenceSLA(), cfg.GetPersistenceTimeout(), statsCollector),
+		ledger: ledger,
+		queue:  make(chan probeJob, cfg.Verifier.QueueSize),
+
+		fingerprints: cfg.UseFingerprints(),
+
+		queueWait: stats.NewHistogram(),
+		timeout:   cfg.GetPersistenceTimeout(),
+	}
+
+	if cfg.VerifyBusinessRules {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		v.oracle, err = NewOracle(ctx, db, ledger.pattern(), &cfg.DeviceStatus)
+		cancel()
+		if err != nil {
+			fmt.Printf("警告: 跳过业务规则校验: %v\n", err)
+		}
+	}
+
+	for range cfg.Verifier.Workers {
+		v.wg.Add(1)
+		go v.runProbes()
+	}
+
+	return v, nil
+}
+
+// runProbes 校验协程：依次执行队列中的探测任务，直到队列关闭
+// 排队已超过最长探测时间的任务计为丢弃，不再探测
+func (v *Verifier) runProbes() {
+	defer v.wg.Done()
+	for job := range v.queue {
+		wait := time.Since(job.ackTime)
+		v.queueWait.Record(wait)
+		if wait >= v.timeout {
+			v.expired.Add(1)
+			v.dropped.Add(1)
+			continue
+		}
+		v.prober.Probe(job.deviceID, job.metricName, job.fingerprint, job.priority, job.ackTime)
+	}
+}
+
+// Oracle 获取业务规则校验器，未启用时返回 nil（nil 的 Oracle 可以安全调用 Begin/Finish）
+func (v *Verifier) Oracle() *Oracle {
+	return v.oracle
+}
+
+// Fingerprint 为 worker 的第 seq 次写入生成唯一指纹，写入不需要携带指纹时返回空字符串
+func (v *Verifier) Fingerprint(worker int, seq uint64) string {
+	if !v.fingerprints {
+		return ""
+	}
+	return v.ledger.Fingerprint(worker, seq)
+}
+
+// Acknowledge 登记一次服务端确认的写入：记录到写入账本，并按采样率提交持久化探测
+// 提交不阻塞，队列已满时丢弃该探测
+func (v *Verifier) Acknowledge(deviceID, metricName, fingerprint string, priority int, ackTime time.Time) {
+	v.ledger.Record(fingerprint, deviceID, metricName, ackTime)
+
+	// 按确认写入的序号均匀抽样，不消耗Worker的随机数流
+	n := v.acked.Add(1)
+	if math.Floor(float64(n)*v.config.SampleRate) == math.Floor(float64(n-1)*v.config.SampleRate) {
+		return
+	}
+	v.sampled.Add(1)
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.closed {
+		v.dropped.Add(1)
+		return
+	}
+	select {
+	case v.queue <- probeJob{deviceID, metricName, fingerprint, priority, ackTime}:
+	default:
+		v.dropped.Add(1)
+	}
+}
+
+// Drain 停止接收探测任务，等待队列中的探测完成并将写入账本刷新到磁盘
+// 应在压测结束、所有Worker停止提交后调用
+func (v *Verifier) Drain() error {
+	v.mu.Lock()
+	v.closed = true
+	close(v.queue)
+	v.mu.Unlock()
+
+	v.wg.Wait()
+	return v.ledger.Close()
+}
+
+// Reconcile 与 time_series_data 对账，统计确认写入但未落库的数量，应在 Drain 之后调用
+func (v *Verifier) Reconcile(ctx context.Context) (*LossResult, error) {
+	return v.ledger.Reconcile(ctx, v.db)
+}
+
+// VerifyBusinessRules 校验业务规则，未启用业务规则校验时返回 nil
+func (v *Verifier) VerifyBusinessRules(ctx context.Context) (*OracleResult, error) {
+	if v.oracle == nil {
+		return nil, nil
+	}
+	return v.oracle.Verify(ctx)
+}
+
+// Close 关闭MySQL连接池
+func (v *Verifier) Close() error {
+	return v.db.Close()
+}
+
+// Report 获取校验子系统自身的运行统计，用于判断校验是否成为瓶颈
+func (v *Verifier) Report() *model.VerifierStats {
+	dbStats := v.db.Stats()
+	waitDurationMs := float32(dbStats.WaitDuration.Seconds() * 1000)
+	return &model.VerifierStats{
+		Sampled:            v.sampled.Load(),
+		Dropped:            v.dropped.Load(),
+		Expired:            v.expired.Load(),
+		QueueWait:          *v.queueWait.GetPercentiles().ToModel(),
+		QueueDepth:         int64(len(v.queue)),
+		PoolWaitCount:      dbStats.WaitCount,
+		PoolWaitDurationMs: waitDurationMs,
+		MaxOpenConnections: int64(dbStats.MaxOpenConnections),
+	}
+}
+
+// PrintReport 打印校验子系统的运行统计
+func (v *Verifier) PrintReport() {
+	r := v.Report()
+	fmt.Printf("\n=== 校验子系统 ===\n")
+	fmt.Printf("抽样探测数: %d (丢弃: %d, 其中排队超时: %d)\n", r.Sampled, r.Dropped, r.Expired)
+	fmt.Printf("排队时间(ms): P50=%.3f, P99=%.3f, P99.9=%.3f\n", r.QueueWait.P50, r.QueueWait.P99, r.QueueWait.P999)
+	fmt.Printf("连接池: 最大连接数 %d, 等待次数 %d, 累计等待 %.2fms\n",
+		r.MaxOpenConnections, r.PoolWaitCount, r.PoolWaitDurationMs)
+	if r.Dropped > 0 || r.PoolWaitCount > 0 {
+		fmt.Printf("提示: 校验队列或连接池已饱和，可调大 verifier.workers、verifier.queue_size 或 verifier.max_open_conns\n")
+	}
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// lockedSource 并发安全的随机数源
+// QPS模式下同一个Worker会被多个请求goroutine同时使用，需要对随机数源加锁
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// deriveSeed 由全局种子和流编号派生出独立的子种子（splitmix64）
+func deriveSeed(seed int64, stream int) int64 {
+	z := uint64(seed) + uint64(stream+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// newRand 创建由全局种子和流编号决定的并发安全随机数生成器
+func newRand(seed int64, stream int) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(deriveSeed(seed, stream)).(rand.Source64)})
+}
+
+// arrivalStreamBase 到达过程随机数流的起始编号，与Worker ID的范围错开
+const arrivalStreamBase = 1 << 30
+
+// NewArrivalRand 创建QPS模式下调度器的到达时间随机数流
+// 与Worker的随机数流相互独立，改变到达过程不影响生成的数据序列
+func NewArrivalRand(seed int64) *rand.Rand {
+	return newRand(seed, arrivalStreamBase)
+}
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"splay/pkg/config"
+	"strings"
+)
+
+// metricNames 接口支持的全部指标名称
+var metricNames = []string{
+	"temperature", "pressure", "humidity", "vibration",
+	"voltage", "current", "power", "flow_rate",
+}
+
+// Topology 工厂/设备拓扑模型
+// 每个工厂有自己的设备数量、指标子集和负载权重，生成数据时先按权重抽取工厂，
+// 再在工厂内抽取设备和指标，使热点工厂的负载特征体现在压测中
+type Topology struct {
+	factories  []factory
+	cumulative []float64 // 工厂负载权重的累积和，用于按权重抽取工厂
+	seed       int64
+}
+
+type factory struct {
+	id      int
+	devices int
+	metrics []string
+	weight  float64
+}
+
+// NewTopology 根据配置创建拓扑模型，cfg 为 nil 时返回 nil（使用均匀随机的设备ID）
+// 拓扑配置未指定种子时使用全局种子生成
+func NewTopology(cfg *config.TopologyConfig, seed int64) (*Topology, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	t := &Topology{seed: cfg.Seed}
+	if len(cfg.Factories) > 0 {
+		for _, f := range cfg.Factories {
+			for _, metric := range f.Metrics {
+				if !slices.Contains(metricNames, metric) {
+					return nil, fmt.Errorf("工厂 %d 的指标名称无效: %s", f.ID, metric)
+				}
+			}
+			t.factories = append(t.factories, factory{
+				id:      f.ID,
+				devices: f.Devices,
+				metrics: f.Metrics,
+				weight:  f.Weight,
+			})
+		}
+	} else {
+		if t.seed == 0 {
+			t.seed = seed
+		}
+		t.generate(cfg, rand.New(rand.NewSource(t.seed)))
+	}
+
+	t.cumulative = make([]float64, len(t.factories))
+	total := 0.0
+	for i, f := range t.factories {
+		total += f.weight
+		t.cumulative[i] = total
+	}
+
+	return t, nil
+}
+
+// generate 按生成参数随机生成工厂列表
+func (t *Topology) generate(cfg *config.TopologyConfig, r *rand.Rand) {
+	maxMetrics := min(cfg.MetricsMax, len(metricNames))
+	minMetrics := min(cfg.MetricsMin, maxMetrics)
+
+	// 负载排名随机分配给工厂，避免工厂ID越小负载越高
+	ranks := r.Perm(cfg.FactoryCount)
+
+	t.factories = make([]factory, cfg.FactoryCount)
+	for i := range t.factories {
+		metricCount := minMetrics + r.Intn(maxMetrics-minMetrics+1)
+		metrics := make([]string, metricCount)
+		for j, idx := range r.Perm(len(metricNames))[:metricCount] {
+			metrics[j] = metricNames[idx]
+		}
+
+		var weight float64
+		switch cfg.Skew {
+		case "zipf":
+			weight = 1 / math.Pow(float64(ranks[i]+1), cfg.SkewParam)
+		case "pareto":
+			weight = 1 / math.Pow(1-r.Float64(), 1/cfg.SkewParam)
+		default:
+			weight = 1
+		}
+
+		t.factories[i] = factory{
+			id:      i + 1,
+			devices: cfg.DevicesMin + r.Intn(cfg.DevicesMax-cfg.DevicesMin+1),
+			metrics: metrics,
+			weight:  weight,
+		}
+	}
+}
+
+// pick 按负载权重抽取一个工厂，再在该工厂内抽取设备和指标
+func (t *Topology) pick(r *rand.Rand) (string, string) {
+	f := &t.factories[t.pickFactory(r)]
+	deviceID := fmt.Sprintf("factory_%03d_device_%08d", f.id, r.Intn(f.devices)+1)
+	return deviceID, f.metrics[r.Intn(len(f.metrics))]
+}
+
+func (t *Topology) pickFactory(r *rand.Rand) int {
+	x := r.Float64() * t.cumulative[len(t.cumulative)-1]
+	return min(sort.SearchFloat64s(t.cumulative, x), len(t.cumulative)-1)
+}
+
+// Summary 返回拓扑的简要描述，包括设备总数和热点工厂的负载占比
+func (t *Topology) Summary() string {
+	totalDevices := 0
+	weights := make([]float64, len(t.factories))
+	for i, f := range t.factories {
+		totalDevices += f.devices
+		weights[i] = f.weight
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(weights)))
+
+	total := t.cumulative[len(t.cumulative)-1]
+	hotCount := max(len(weights)/10, 1)
+	hotWeight := 0.0
+	for _, weight := range weights[:hotCount] {
+		hotWeight += weight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d 个工厂, %d 个设备, 最热的 %d 个工厂承担 %.1f%% 负载",
+		len(t.factories), totalDevices, hotCount, hotWeight*100/total)
+	if t.seed != 0 {
+		fmt.Fprintf(&b, " (种子: %d)", t.seed)
+	}
+	return b.String()
+}
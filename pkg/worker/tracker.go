@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"math"
+	"sync"
+)
+
+// alertThreshold 业务告警阈值，数值超过该值时服务端应触发高优先级告警
+const alertThreshold = 100.0
+
+// WriteTracker 记录客户端对每个设备/指标确认写入的最后数值（包括上报、读写和批量读写写入），
+// 用于校验读写接口返回的 previous_value。
+//
+// 同一键上存在并发写入时服务端的执行顺序无法确定，此时不做校验，
+// 只有独占该键的写入（开始时没有其他进行中的写入，且完成前没有新的写入开始）才会被校验。
+//
+// 跟踪的键数超过上限时淘汰最近没有写入的键，被淘汰的键下一次写入不做校验。
+type WriteTracker struct {
+	mu      sync.Mutex
+	entries map[string]*trackedValue
+	limit   int    // 跟踪的键数上限
+	clock   uint64 // 每次开始写入时递增，记录键最近一次写入的先后
+}
+
+type trackedValue struct {
+	value    float64 // 最后一次确认写入的数值
+	known    bool    // 当前数值是否可信
+	inflight int     // 正在进行中的写入数
+	version  uint64  // 每次开始写入时递增
+	used     uint64  // 最近一次开始写入时的 clock
+}
+
+// writeTicket 一次写入的登记凭证
+type writeTicket struct {
+	key      string
+	version  uint64
+	previous float64 // 写入开始时确认的数值
+	known    bool    // 写入开始时数值是否可信且没有其他进行中的写入
+}
+
+// maxTrackedKeys 写入跟踪器默认跟踪的键数上限
+const maxTrackedKeys = 1 << 20
+
+// NewWriteTracker 创建写入跟踪器，所有Worker应共享同一个跟踪器
+func NewWriteTracker() *WriteTracker {
+	return &WriteTracker{entries: make(map[string]*trackedValue), limit: maxTrackedKeys}
+}
+
+func trackerKey(deviceID, metricName string) string {
+	return deviceID + "|" + metricName
+}
+
+// begin 登记一次写入开始
+func (t *WriteTracker) begin(deviceID, metricName string) writeTicket {
+	key := trackerKey(deviceID, metricName)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.clock++
+	e, ok := t.entries[key]
+	if !ok {
+		if len(t.entries) >= t.limit {
+			t.evict()
+		}
+		e = &trackedValue{}
+		t.entries[key] = e
+	}
+	e.inflight++
+	e.version++
+	e.used = t.clock
+
+	return writeTicket{
+		key:      key,
+		version:  e.version,
+		previous: e.value,
+		known:    e.known && e.inflight == 1,
+	}
+}
+
+// evict 淘汰最近 limit/2 次写入都没有涉及、且没有进行中写入的键，调用方需持有锁
+// 淘汰后剩余的键不超过 limit/2 加上进行中写入涉及的键，下一次淘汰至少在 limit/2 个新键之后，均摊开销为常数
+func (t *WriteTracker) evict() {
+	recent := uint64(t.limit / 2)
+	for key, e := range t.entries {
+		if e.inflight == 0 && t.clock-e.used > recent {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// finish 登记一次写入完成，返回该写入是否独占该键（即 previous_value 是否可校验）
+func (t *WriteTracker) finish(ticket writeTicket, value float64, acked bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e := t.entries[ticket.key]
+	e.inflight--
+	latest := e.version == ticket.version
+
+	switch {
+	case acked && latest:
+		e.value = value
+		e.known = true
+	default:
+		// 写入失败时无法确定服务端是否已生效，写入交错时无法确定最终顺序
+		e.known = false
+	}
+
+	return ticket.known && latest
+}
+
+// valuesEqual 比较两个传感器数值是否一致
+func valuesEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(a))
+}
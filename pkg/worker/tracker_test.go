@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWriteTrackerExclusive(t *testing.T) {
+	tracker := NewWriteTracker()
+
+	// 第一次写入之前没有可信的数值
+	first := tracker.begin("d1", "temperature")
+	if tracker.finish(first, 10, true) {
+		t.Errorf("第一次写入被校验")
+	}
+
+	second := tracker.begin("d1", "temperature")
+	if !second.known || second.previous != 10 {
+		t.Errorf("previous = %v known = %v, want 10 true", second.previous, second.known)
+	}
+	if !tracker.finish(second, 20, true) {
+		t.Errorf("独占的写入未被校验")
+	}
+
+	// 交错的写入都不校验，之后的数值不可信
+	a := tracker.begin("d1", "temperature")
+	b := tracker.begin("d1", "temperature")
+	if tracker.finish(b, 40, true) || tracker.finish(a, 30, true) {
+		t.Errorf("交错的写入被校验")
+	}
+	if next := tracker.begin("d1", "temperature"); next.known {
+		t.Errorf("交错写入后的数值仍被认为可信")
+	}
+}
+
+func TestWriteTrackerEvict(t *testing.T) {
+	tracker := &WriteTracker{entries: make(map[string]*trackedValue), limit: 8}
+
+	// 进行中的写入不会被淘汰
+	pending := tracker.begin("pending", "temperature")
+	for i := range 100 {
+		ticket := tracker.begin(fmt.Sprintf("d%d", i), "temperature")
+		tracker.finish(ticket, float64(i), true)
+		if len(tracker.entries) > tracker.limit {
+			t.Fatalf("跟踪的键数 %d 超过上限 %d", len(tracker.entries), tracker.limit)
+		}
+	}
+	if _, ok := tracker.entries[pending.key]; !ok {
+		t.Fatalf("进行中写入的键被淘汰")
+	}
+	tracker.finish(pending, 1, true)
+
+	// 最近写入的键保留，被淘汰的键重新写入时不校验
+	if ticket := tracker.begin("d99", "temperature"); !ticket.known || ticket.previous != 99 {
+		t.Errorf("最近写入的键 previous = %v known = %v, want 99 true", ticket.previous, ticket.known)
+	}
+	if ticket := tracker.begin("d0", "temperature"); ticket.known {
+		t.Errorf("被淘汰的键仍有可信的数值")
+	}
+}
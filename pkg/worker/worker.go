@@ -2,13 +2,15 @@
 //
 // 需求和预设:
 // 1. 时序数据API支持: 实现对传感器数据上报、读写操作、批量操作、查询操作的测试
-// 2. 真实数据模拟: 模拟3000个工厂的传感器数据，包含设备ID、指标类型、数值、优先级等
+// 2. 真实数据模拟: 模拟3000个工厂的传感器数据，包含设备ID、指标类型、数值、优先级等，支持按拓扑模型生成热点工厂负载
 // 3. 业务逻辑测试: 支持阈值监控测试(数值>100触发高优先级告警)
-// 4. 可配置负载: 根据配置生成不同大小的随机负载数据(512B-20KB)
+// 4. 可配置负载: 根据负载大小分布(固定、均匀、对数正态、直方图)生成随机负载数据(如512B-20KB)
 // 5. 工作队列模式: 从RateController接收工作项，按需执行操作
 // 6. 统计推送: 将操作结果推送给StatsCollector进行统计
 // 7. 上下文支持: 支持优雅的取消和超时控制
 // 8. 错误处理: 区分不同类型的错误，提供详细的错误统计
+// 9. 响应校验: 校验读写接口返回的 previous_value 与告警信息是否符合业务逻辑
+// 10. 可重现性: 每个Worker使用由全局种子派生的独立随机数流，相同种子和配置生成相同的数据序列
 //
 // 设计原则:
 // - 每个Worker独立运行，互不影响
@@ -20,29 +22,26 @@ package worker
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"log"
+	"math"
 	"math/rand"
 	"splay/client"
 	"splay/pkg/config"
 	"splay/pkg/stats"
+	"splay/pkg/verifier"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 // 常量定义
 const (
-	charset              = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	dataSize             = 64  // 固定数据大小
-	queryTriggerInterval = 100 // 每100个读请求触发一次查询验证
+	charset  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	dataSize = 64 // 字节切片池的初始容量
 )
 
-// 查询计数器，用于每100个读请求触发一次验证
-var queryCounter int64
+// 查询时间窗口，模拟常见的监控面板查询范围
+var queryWindows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour, 24 * time.Hour}
 
 // 对象池
 var (
@@ -88,111 +87,434 @@ type Worker struct {
 	client         *client.ClientWithResponses
 	statsCollector *stats.Collector
 	config         *config.Config
+	topology       *Topology
+	tracker        *WriteTracker      // 所有Worker共享的写入跟踪器，用于校验 previous_value
+	verifier       *verifier.Verifier // 校验子系统：业务规则跟踪、写入指纹和持久化探测
+	rng            *rand.Rand         // 由全局种子和Worker ID派生的随机数流
+	writes         atomic.Uint64      // 已生成指纹的写入数，作为写入指纹中的Worker内序号
 }
 
-func New(id int, client *client.ClientWithResponses, statsCollector *stats.Collector, cfg *config.Config) *Worker {
+func New(id int, client *client.ClientWithResponses, statsCollector *stats.Collector, cfg *config.Config,
+	topology *Topology, tracker *WriteTracker, v *verifier.Verifier) *Worker {
 	return &Worker{
 		id:             id,
 		client:         client,
 		statsCollector: statsCollector,
 		config:         cfg,
+		topology:       topology,
+		tracker:        tracker,
+		verifier:       v,
+		rng:            newRand(cfg.Seed, id),
 	}
 }
 
-// ExecuteOperation 执行单个操作（用于QPS模式的独立goroutine）
-func (w *Worker) ExecuteOperation() {
-	w.doSensorDataUpload()
+// Rand 获取Worker的随机数生成器，流量控制器用它选择操作类型以保证可重现
+func (w *Worker) Rand() *rand.Rand {
+	return w.rng
+}
+
+// fingerprint 为下一次写入生成指纹
+func (w *Worker) fingerprint() string {
+	return w.verifier.Fingerprint(w.id, w.writes.Add(1))
+}
+
+// ExecuteOperation 按操作类型执行单个操作
+// scheduled 为QPS模式下请求的计划发送时刻，用于计算包含客户端排队延迟的响应时间，
+// 避免协调遗漏（coordinated omission）；并发模式下为零值，响应时间等于服务时间
+// tick 为QPS模式下使用重复时间戳的请求所属的采样时刻，写入的时间戳都使用该时刻；其他请求为零值
+func (w *Worker) ExecuteOperation(opType string, scheduled, tick time.Time) {
+	switch opType {
+	case "sensor-rw":
+		w.doSensorReadWrite(scheduled, tick)
+	case "batch-rw":
+		w.doBatchSensorReadWrite(scheduled, tick)
+	case "query":
+		w.doQuerySensorData(scheduled)
+	default:
+		w.doSensorDataUpload(scheduled, tick)
+	}
 }
 
 // doSensorDataUpload 传感器数据上报
-func (w *Worker) doSensorDataUpload() {
-	deviceID := w.generateDeviceID()
-	metricName := w.generateMetricName()
+func (w *Worker) doSensorDataUpload(scheduled, tick time.Time) {
+	deviceID, metricName := w.generateSensor()
 	value := w.generateValue()
 	priority := w.generatePriority()
-	data := w.generateRandomData()
+	// 负载末尾嵌入唯一的写入指纹（不改变负载长度），用于持久化探测和数据丢失对账
+	fingerprint := w.fingerprint()
+	data := verifier.EmbedFingerprint(w.generateRandomData(), fingerprint)
 
 	// 从池中获取请求对象
 	request := sensorDataRequestPool.Get().(*client.UploadSensorDataJSONRequestBody)
 	defer sensorDataRequestPool.Put(request)
 
 	// 立即记录发送事件
-	w.statsCollector.PushSentEvent("sensor-data")
+	w.statsCollector.PushSentEvent("sensor-data", len(data))
+
+	// 上报写入与读写接口写入相同的设备/指标，同样登记到写入跟踪器和业务规则校验器，
+	// 否则读写接口校验的 previous_value 和 device_status 的最新值、告警计数可能来自未跟踪的上报
+	ticket := w.tracker.begin(deviceID, metricName)
+	oracleTicket := w.verifier.Oracle().Begin(deviceID, fingerprint)
 
 	startTime := time.Now()
 	// 重用request对象
 	request.DeviceId = deviceID
 	request.MetricName = client.SensorDataMetricName(metricName)
 	request.Value = value
-	request.Timestamp = startTime
+	request.Timestamp = w.generateTimestamp(startTime, scheduled, tick)
 	request.Priority = &priority
 	request.Data = &data
 
 	resp, err := w.client.UploadSensorDataWithResponse(context.Background(), *request)
 	latency := time.Since(startTime)
 
-	success := err == nil && resp.StatusCode() == 200
 	// 记录完成事件
-	w.statsCollector.PushCompletedResult("sensor-data", latency, priority, success)
+	success := w.pushCompletedResult("sensor-data", latency, responseTime(scheduled, startTime, latency), priority,
+		err, statusOf(resp, err))
+	w.tracker.finish(ticket, value, success)
+	w.verifier.Oracle().Finish(oracleTicket, value, success)
 
-	// 每100个写入请求后启动goroutine进行查询验证
-	if atomic.AddInt64(&queryCounter, 1)%queryTriggerInterval == 0 {
-		go w.verifyDataInMySQL(deviceID, metricName, priority)
+	// 确认的写入记录到写入账本，并按采样率探测数据何时在MySQL中可见
+	if success {
+		w.verifier.Acknowledge(deviceID, metricName, fingerprint, priority, startTime.Add(latency))
 	}
 }
 
-// verifyDataInMySQL 验证MySQL中的数据写入
-func (w *Worker) verifyDataInMySQL(deviceID, metricName string, priority int) {
-	// 等待3秒让数据写入MySQL
-	time.Sleep(3 * time.Second)
+// doSensorReadWrite 传感器数据读写操作（带事务）
+func (w *Worker) doSensorReadWrite(scheduled, tick time.Time) {
+	deviceID, metricName := w.generateSensor()
+	value := w.generateValue()
+	priority := w.generatePriority()
+	fingerprint := w.fingerprint()
+	data := verifier.EmbedFingerprint(w.generateRandomData(), fingerprint)
 
-	// 连接MySQL数据库
-	db, err := sql.Open("mysql", w.config.MySQLDSN)
-	if err != nil {
-		log.Println("Failed to connect to MySQL:", err)
-		w.statsCollector.PushCompletedResult("verify-query", 0, priority, false)
-		return
+	// 从池中获取请求对象
+	request := sensorRWRequestPool.Get().(*client.SensorReadWriteJSONRequestBody)
+	defer sensorRWRequestPool.Put(request)
+
+	w.statsCollector.PushSentEvent("sensor-rw", len(data))
+
+	ticket := w.tracker.begin(deviceID, metricName)
+	oracleTicket := w.verifier.Oracle().Begin(deviceID, fingerprint)
+
+	startTime := time.Now()
+	request.DeviceId = deviceID
+	request.MetricName = metricName
+	request.NewValue = value
+	request.Timestamp = w.generateTimestamp(startTime, scheduled, tick)
+	request.Priority = &priority
+	request.Data = &data
+
+	resp, err := w.client.SensorReadWriteWithResponse(context.Background(), *request)
+	latency := time.Since(startTime)
+
+	success := w.pushCompletedResult("sensor-rw", latency, responseTime(scheduled, startTime, latency), priority,
+		err, statusOf(resp, err))
+	exclusive := w.tracker.finish(ticket, value, success)
+	w.verifier.Oracle().Finish(oracleTicket, value, success)
+
+	if success {
+		w.verifier.Acknowledge(deviceID, metricName, fingerprint, priority, startTime.Add(latency))
+	}
+	if success && !w.validateSensorReadWrite(resp.JSON200, ticket, exclusive, value) {
+		w.statsCollector.PushValidationError("sensor-rw")
+	}
+}
+
+// validateSensorReadWrite 校验读写接口的返回数据
+// new_value 必须与请求一致，previous_value 必须等于客户端对该设备/指标最后写入的数值
+// （仅在可确定时校验），new_value > 100 时必须返回告警信息
+func (w *Worker) validateSensorReadWrite(data *client.SensorReadWriteData, ticket writeTicket, exclusive bool, value float64) bool {
+	if data == nil {
+		return false
+	}
+
+	if data.NewValue != nil && !valuesEqual(*data.NewValue, value) {
+		return false
+	}
+
+	if exclusive && (data.PreviousValue == nil || !valuesEqual(*data.PreviousValue, ticket.previous)) {
+		return false
+	}
+
+	if value > alertThreshold && (data.Alert == nil || *data.Alert == "") {
+		return false
+	}
+
+	return true
+}
+
+// doBatchSensorReadWrite 批量传感器数据读写操作
+func (w *Worker) doBatchSensorReadWrite(scheduled, tick time.Time) {
+	batchSize := w.sampleSize(&w.config.BatchSize)
+
+	// 从池中获取批量请求切片
+	items := batchRequestPool.Get().([]client.SensorReadWriteRequest)[:0]
+	defer func() {
+		batchRequestPool.Put(items[:0])
+	}()
+
+	// 数据项的时间戳取生成时刻，请求延迟从发送前开始计算，不包含客户端生成数据的时间
+	itemTime := time.Now()
+	tickets := make([]writeTicket, 0, batchSize)
+	oracleTickets := make([]verifier.Ticket, 0, batchSize)
+	fingerprints := make([]string, 0, batchSize)
+	expectedAlerts := 0
+	payloadBytes := 0
+	for range batchSize {
+		deviceID, metricName := w.generateSensor()
+		value := w.generateValue()
+		priority := w.generatePriority()
+		fingerprint := w.fingerprint()
+		data := verifier.EmbedFingerprint(w.generateRandomData(), fingerprint)
+		fingerprints = append(fingerprints, fingerprint)
+		items = append(items, client.SensorReadWriteRequest{
+			DeviceId:   deviceID,
+			MetricName: metricName,
+			NewValue:   value,
+			Timestamp:  w.generateTimestamp(itemTime, scheduled, tick),
+			Priority:   &priority,
+			Data:       &data,
+		})
+		tickets = append(tickets, w.tracker.begin(deviceID, metricName))
+		oracleTickets = append(oracleTickets, w.verifier.Oracle().Begin(deviceID, fingerprint))
+		if value > alertThreshold {
+			expectedAlerts++
+		}
+		payloadBytes += len(data)
+	}
+
+	w.statsCollector.PushSentEvent("batch-rw", payloadBytes)
+
+	startTime := time.Now()
+	resp, err := w.client.BatchSensorReadWriteWithResponse(context.Background(), client.BatchSensorReadWriteJSONRequestBody{Data: items})
+	latency := time.Since(startTime)
+
+	status := statusOf(resp, err)
+	success := err == nil && status == 200
+	for i, ticket := range tickets {
+		w.tracker.finish(ticket, items[i].NewValue, success)
+		w.verifier.Oracle().Finish(oracleTickets[i], items[i].NewValue, success)
+		if success {
+			w.verifier.Acknowledge(items[i].DeviceId, items[i].MetricName, fingerprints[i], *items[i].Priority, startTime.Add(latency))
+		}
+	}
+	if success {
+		w.statsCollector.PushCompletedBatchResult("batch-rw", latency, responseTime(scheduled, startTime, latency), len(items), true)
+	} else {
+		w.statsCollector.PushFailedResult("batch-rw", latency, 0, err, status)
+	}
+
+	if success && !validateBatchSensorReadWrite(resp.JSON200, len(items), expectedAlerts) {
+		w.statsCollector.PushValidationError("batch-rw")
+	}
+}
+
+// validateBatchSensorReadWrite 校验批量接口返回的处理数量和告警数量是否与发送的数据一致
+func validateBatchSensorReadWrite(data *client.BatchSensorReadWriteData, expectedProcessed, expectedAlerts int) bool {
+	if data == nil || data.TotalProcessed == nil || data.TotalAlerts == nil {
+		return false
+	}
+	return *data.TotalProcessed == expectedProcessed && *data.TotalAlerts == expectedAlerts
+}
+
+// doQuerySensorData 传感器时序数据查询
+// 随机选择一个时间窗口和可选的指标名称，按 limit/offset 翻页查询，每页作为一次查询操作统计
+func (w *Worker) doQuerySensorData(scheduled time.Time) {
+	// 从池中获取请求对象
+	request := queryRequestPool.Get().(*client.GetSensorDataJSONRequestBody)
+	defer queryRequestPool.Put(request)
+
+	// 数据库时间戳精度为毫秒，窗口边界对齐到毫秒避免边界记录被误判
+	endTime := time.Now().Truncate(time.Millisecond)
+	limit := w.config.QueryLimit
+	deviceID, queryMetric := w.generateSensor()
+	request.DeviceId = deviceID
+	request.StartTime = endTime.Add(-queryWindows[w.rng.Intn(len(queryWindows))])
+	request.EndTime = endTime
+	request.Limit = &limit
+	request.MetricName = nil
+	if w.rng.Intn(2) == 0 {
+		metricName := client.GetSensorDataRequestMetricName(queryMetric)
+		request.MetricName = &metricName
 	}
-	defer db.Close()
 
-	// 查询刚写入的数据
-	queryStart := time.Now()
-	query := `SELECT COUNT(*) FROM time_series_data 
-		WHERE device_id = ? AND metric_name = ?`
+	// 记录已返回的记录，用于统计分页重叠
+	seen := make(map[string]struct{})
 
-	var count int
-	err = db.QueryRow(query, deviceID, metricName).Scan(&count)
-	queryLatency := time.Since(queryStart)
+	for page := range w.config.QueryMaxPages {
+		offset := page * limit
+		request.Offset = &offset
 
-	// 记录验证结果
-	success := err == nil && count > 0
-	w.statsCollector.PushCompletedResult("verify-query", queryLatency, priority, success)
+		w.statsCollector.PushSentEvent("query", 0)
+
+		startTime := time.Now()
+		resp, err := w.client.GetSensorDataWithResponse(context.Background(), *request)
+		latency := time.Since(startTime)
+
+		// 只有第一页是计划发送的请求，后续翻页紧接着上一页发送
+		if !w.pushCompletedResult("query", latency, responseTime(scheduled, startTime, latency), 0,
+			err, statusOf(resp, err)) {
+			return
+		}
+		scheduled = time.Time{}
+
+		count, overlaps, valid := validateQueryPage(resp.JSON200, request, seen)
+		if overlaps > 0 {
+			w.statsCollector.PushPageOverlap("query", overlaps)
+		}
+		if !valid {
+			w.statsCollector.PushValidationError("query")
+			return
+		}
+
+		// 最后一页
+		if count < limit {
+			return
+		}
+	}
+}
+
+// validateQueryPage 校验一页查询结果: count≤limit 且与返回条数一致，
+// 每条记录属于查询的设备（及指标）并位于时间窗口内；返回本页条数和与之前的页重复的记录数
+//
+// 查询窗口截止到当前时刻，翻页期间新写入的记录会使后面的页按 offset 错位，与之前的页重叠是正常现象，
+// 因此重叠只计数，不算校验失败
+func validateQueryPage(data *client.GetSensorDataData, request *client.GetSensorDataJSONRequestBody,
+	seen map[string]struct{}) (count, overlaps int, valid bool) {
+	if data == nil || data.Count == nil {
+		return 0, 0, false
+	}
+
+	count = *data.Count
+	var records []client.SensorDataRecord
+	if data.Data != nil {
+		records = *data.Data
+	}
+	if count > *request.Limit || count != len(records) {
+		return count, 0, false
+	}
+
+	for _, record := range records {
+		if record.DeviceId == nil || *record.DeviceId != request.DeviceId {
+			return count, overlaps, false
+		}
+		if request.MetricName != nil && (record.MetricName == nil || *record.MetricName != string(*request.MetricName)) {
+			return count, overlaps, false
+		}
+		if record.Timestamp == nil || record.Timestamp.Before(request.StartTime) || record.Timestamp.After(request.EndTime) {
+			return count, overlaps, false
+		}
+
+		key := recordKey(&record)
+		if _, ok := seen[key]; ok {
+			overlaps++
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+
+	return count, overlaps, true
+}
+
+// recordKey 返回记录的唯一标识，优先使用记录ID
+func recordKey(record *client.SensorDataRecord) string {
+	if record.Id != nil {
+		return fmt.Sprintf("id:%d", *record.Id)
+	}
+
+	var metricName string
+	var value float64
+	if record.MetricName != nil {
+		metricName = *record.MetricName
+	}
+	if record.Value != nil {
+		value = *record.Value
+	}
+	return fmt.Sprintf("%s|%s|%s|%v", *record.DeviceId, metricName, record.Timestamp.Format(time.RFC3339Nano), value)
+}
+
+// pushCompletedResult 推送请求完成结果，失败时按错误类型和HTTP状态码分类，返回请求是否成功
+func (w *Worker) pushCompletedResult(operation string, latency, responseTime time.Duration, priority int,
+	err error, statusCode int) bool {
+	if err == nil && statusCode == 200 {
+		w.statsCollector.PushCompletedResult(operation, latency, responseTime, priority, true)
+		return true
+	}
+	w.statsCollector.PushFailedResult(operation, latency, priority, err, statusCode)
+	return false
+}
+
+// responseTime 计算从计划发送时刻到请求完成的响应时间，没有计划发送时刻时等于服务时间
+func responseTime(scheduled, startTime time.Time, latency time.Duration) time.Duration {
+	if scheduled.IsZero() || scheduled.After(startTime) {
+		return latency
+	}
+	return startTime.Add(latency).Sub(scheduled)
+}
+
+// statusOf 获取响应的HTTP状态码，请求出错（未收到响应或响应解析失败）时返回0
+func statusOf(resp interface{ StatusCode() int }, err error) int {
+	if err != nil {
+		return 0
+	}
+	return resp.StatusCode()
+}
+
+// generateSensor 生成设备ID和指标名称，配置了拓扑模型时从拓扑中抽取
+func (w *Worker) generateSensor() (string, string) {
+	if w.topology != nil {
+		return w.topology.pick(w.rng)
+	}
+	return w.generateDeviceID(), w.generateMetricName()
 }
 
 // generateDeviceID 生成设备ID
 func (w *Worker) generateDeviceID() string {
-	factoryID := rand.Intn(3000) + 1 // 工厂ID 1-3000
-	deviceID := rand.Intn(w.config.KeyRange) + 1
+	factoryID := w.rng.Intn(3000) + 1 // 工厂ID 1-3000
+	deviceID := w.rng.Intn(w.config.KeyRange) + 1
 	return fmt.Sprintf("factory_%03d_device_%08d", factoryID, deviceID)
 }
 
 // generateMetricName 生成指标名称
 func (w *Worker) generateMetricName() string {
-	metrics := []string{
-		"temperature", "pressure", "humidity", "vibration",
-		"voltage", "current", "power", "flow_rate",
+	return metricNames[w.rng.Intn(len(metricNames))]
+}
+
+// generateTimestamp 生成传感器采样时间戳
+// 启用采样时钟时，带采样时刻 tick 的请求（QPS模式下周期边界上的突发请求和调度器抽中的重复请求）使用 tick 作为时间戳；
+// 并发模式（scheduled 为零值）按 duplicate_ratio 的比例使用当前采样周期边界作为时间戳。
+// 其余请求的时间戳在发送前一个周期内均匀分布，取微秒精度，避免非重复的时间戳相互碰撞
+func (w *Worker) generateTimestamp(now, scheduled, tick time.Time) time.Time {
+	clock := &w.config.SamplingClock
+	if !clock.Enabled {
+		return now
 	}
-	return metrics[rand.Intn(len(metrics))]
+	if !tick.IsZero() {
+		return tick
+	}
+
+	period := clock.GetTick()
+	if scheduled.IsZero() && w.rng.Float64() < clock.DuplicateRatio {
+		return now.Truncate(period)
+	}
+
+	ts := now.Add(-time.Duration(w.rng.Int63n(int64(period/time.Microsecond))) * time.Microsecond).Truncate(time.Microsecond)
+	if ts.Equal(ts.Truncate(period)) {
+		// 恰好落在周期边界上的时间戳会与重复时间戳碰撞
+		ts = ts.Add(time.Microsecond)
+	}
+	return ts
 }
 
 // generateValue 生成传感器数值
 func (w *Worker) generateValue() float64 {
 	// 99% 的概率生成正常值 (0-100)
 	// 1% 的概率生成异常值 (100-200)，触发告警
-	if rand.Float64() < 0.99 {
-		return rand.Float64() * 100
+	if w.rng.Float64() < 0.99 {
+		return w.rng.Float64() * 100
 	} else {
-		return 100 + rand.Float64()*100
+		return 100 + w.rng.Float64()*100
 	}
 }
 
@@ -203,7 +525,7 @@ func (w *Worker) generatePriority() int {
 	priorities := []int{1, 2, 3}
 	weights := []float64{0.2, 0.6, 0.2} // 高、中、低优先级的权重
 
-	r := rand.Float64()
+	r := w.rng.Float64()
 	cumulative := 0.0
 	for i, weight := range weights {
 		cumulative += weight
@@ -214,15 +536,63 @@ func (w *Worker) generatePriority() int {
 	return 2 // 默认中优先级
 }
 
-// generateRandomData 生成固定64字节的负载数据
+// sampleSize 按分布配置抽取一个数量
+func (w *Worker) sampleSize(d *config.SizeDistribution) int {
+	switch d.Type {
+	case "uniform":
+		return d.Min + w.rng.Intn(d.Max-d.Min+1)
+	case "weighted":
+		totalWeight := 0.0
+		for _, weight := range d.Weights {
+			totalWeight += weight
+		}
+		r := w.rng.Float64() * totalWeight
+		cumulative := 0.0
+		for i, weight := range d.Weights {
+			cumulative += weight
+			if r < cumulative {
+				return d.Values[i]
+			}
+		}
+		return d.Values[len(d.Values)-1]
+	case "lognormal":
+		size := int(math.Round(d.Median * math.Exp(d.Sigma*w.rng.NormFloat64())))
+		return min(max(size, d.Min), d.Max)
+	case "histogram":
+		totalWeight := 0.0
+		for _, bucket := range d.Buckets {
+			totalWeight += bucket.Weight
+		}
+		r := w.rng.Float64() * totalWeight
+		cumulative := 0.0
+		for _, bucket := range d.Buckets {
+			cumulative += bucket.Weight
+			if r < cumulative {
+				return bucket.Min + w.rng.Intn(bucket.Max-bucket.Min+1)
+			}
+		}
+		last := d.Buckets[len(d.Buckets)-1]
+		return last.Min + w.rng.Intn(last.Max-last.Min+1)
+	default:
+		return d.Value
+	}
+}
+
+// generateRandomData 按负载大小分布生成负载数据
 func (w *Worker) generateRandomData() string {
-	// 从池中获取字节切片
+	size := w.sampleSize(&w.config.PayloadSize)
+
+	// 从池中获取字节切片，容量不足时重新分配
 	b := byteSlicePool.Get().([]byte)
+	if cap(b) < size {
+		b = make([]byte, size)
+	}
+	b = b[:size]
 	defer byteSlicePool.Put(b)
 
 	// 生成随机数据
-	charId := rand.Intn(len(charset))
-	for i := range dataSize {
+	charId := w.rng.Intn(len(charset))
+	for i := range size {
 		b[i] = charset[charId]
 		charId = ((charId + 3) / 7 >> 2) % len(charset)
 	}
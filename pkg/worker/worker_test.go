@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"splay/client"
+	"splay/pkg/config"
+)
+
+// TestGenerateTimestampDuplicates 固定种子下实际的重复时间戳比例等于 duplicate_ratio，非重复的时间戳互不相同
+func TestGenerateTimestampDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		ratio     float64
+		scheduled bool // QPS模式：重复由调度器决定，未带采样时刻的请求都不重复
+		want      float64
+	}{
+		{"并发模式", 0.6, false, 0.6},
+		{"并发模式-无重复", 0, false, 0},
+		{"QPS模式均匀发送", 0.6, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.SamplingClock = config.SamplingClockConfig{Enabled: true, TickMs: 1000, DuplicateRatio: tt.ratio}
+			w := &Worker{config: cfg, rng: newRand(1, 0)}
+
+			// 10000 QPS 持续 5 个采样周期
+			const n = 50000
+			start := time.Unix(1700000000, 0)
+			seen := make(map[time.Time]bool, n)
+			duplicates := 0
+			for i := range n {
+				now := start.Add(time.Duration(i) * 100 * time.Microsecond)
+				var scheduled time.Time
+				if tt.scheduled {
+					scheduled = now
+				}
+				ts := w.generateTimestamp(now, scheduled, time.Time{})
+				if ts.After(now) {
+					t.Fatalf("时间戳 %v 晚于发送时刻 %v", ts, now)
+				}
+				if ts.Equal(ts.Truncate(time.Second)) {
+					duplicates++
+					continue
+				}
+				if now.Sub(ts) >= time.Second {
+					t.Fatalf("时间戳 %v 早于发送前一个周期", ts)
+				}
+				seen[ts] = true
+			}
+
+			if got := float64(duplicates) / n; math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("重复时间戳比例 = %.4f, want %.2f", got, tt.want)
+			}
+			// 微秒精度下非重复时间戳的碰撞应远少于重复比例的误差范围
+			if collisions := n - duplicates - len(seen); float64(collisions)/n > 0.01 {
+				t.Errorf("非重复时间戳碰撞 %d 次", collisions)
+			}
+		})
+	}
+}
+
+// TestGenerateTimestampTick 带采样时刻的请求使用该时刻作为时间戳
+func TestGenerateTimestampTick(t *testing.T) {
+	cfg := config.New()
+	cfg.SamplingClock = config.SamplingClockConfig{Enabled: true, TickMs: 1000, DuplicateRatio: 0.6}
+	w := &Worker{config: cfg, rng: newRand(1, 0)}
+
+	tick := time.Unix(1700000000, 0)
+	now := tick.Add(3 * time.Millisecond)
+	if ts := w.generateTimestamp(now, now, tick); !ts.Equal(tick) {
+		t.Errorf("timestamp = %v, want %v", ts, tick)
+	}
+}
+
+func TestValidateQueryPage(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limit := 3
+	request := &client.GetSensorDataJSONRequestBody{
+		DeviceId:  "device_1",
+		StartTime: end.Add(-time.Minute),
+		EndTime:   end,
+		Limit:     &limit,
+	}
+	record := func(id int64, deviceID string, ts time.Time) client.SensorDataRecord {
+		return client.SensorDataRecord{Id: &id, DeviceId: &deviceID, Timestamp: &ts}
+	}
+	page := func(records ...client.SensorDataRecord) *client.GetSensorDataData {
+		count := len(records)
+		return &client.GetSensorDataData{Count: &count, Data: &records}
+	}
+	ts := end.Add(-time.Second)
+
+	tests := []struct {
+		name         string
+		first        *client.GetSensorDataData // 之前的页
+		page         *client.GetSensorDataData
+		wantOverlaps int
+		wantValid    bool
+	}{
+		{"正常翻页", page(record(1, "device_1", ts)), page(record(2, "device_1", ts)), 0, true},
+		{"与之前的页重叠只计数", page(record(1, "device_1", ts), record(2, "device_1", ts)),
+			page(record(2, "device_1", ts), record(3, "device_1", ts)), 1, true},
+		{"其他设备的记录", nil, page(record(1, "device_2", ts)), 0, false},
+		{"超出时间窗口", nil, page(record(1, "device_1", end.Add(time.Second))), 0, false},
+		{"条数超过 limit", nil, page(record(1, "device_1", ts), record(2, "device_1", ts),
+			record(3, "device_1", ts), record(4, "device_1", ts)), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]struct{})
+			if tt.first != nil {
+				validateQueryPage(tt.first, request, seen)
+			}
+			_, overlaps, valid := validateQueryPage(tt.page, request, seen)
+			if overlaps != tt.wantOverlaps || valid != tt.wantValid {
+				t.Errorf("validateQueryPage = %d %v, want %d %v", overlaps, valid, tt.wantOverlaps, tt.wantValid)
+			}
+		})
+	}
+}